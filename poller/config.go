@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dispatch"
 	"github.com/rs/zerolog/log"
 )
 
@@ -15,25 +19,100 @@ const respHeader = "response header"
 const respBody = "response body"
 const respStatus = "status"
 
-func setupTicker() *time.Ticker {
-	var ticker *time.Ticker
+type config struct {
+	dispatch.Config
+	PollPeriod        dispatch.Duration `json:"poll_period"`
+	Stream            bool              `json:"stream"`
+	StreamIdleTimeout dispatch.Duration `json:"stream_idle_timeout"`
+	PollerID          string            `json:"poller_id"`
+	ReminderURL       string            `json:"reminder_url"`
+}
+
+func defaultConfig() config {
+	dispatchCfg := dispatch.DefaultConfig()
+	dispatchCfg.JournalFile = "poller.journal"
+
+	return config{
+		Config:            dispatchCfg,
+		PollPeriod:        dispatch.Duration(time.Minute),
+		Stream:            true,
+		StreamIdleTimeout: dispatch.Duration(time.Minute),
+		ReminderURL:       "http://reminder:8080",
+	}
+}
+
+// loadConfig reads the config file named by POLLER_CONFIG, if any, and
+// overrides its values with the ones set in the environment.
+func loadConfig() (config, error) {
+	cfg := defaultConfig()
+
+	if path := os.Getenv("POLLER_CONFIG"); path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return config{}, fmt.Errorf("read config file: %w", err)
+		}
+	}
+
+	if err := cfg.readEnv(); err != nil {
+		return config{}, fmt.Errorf("read env: %w", err)
+	}
 
-	durationString := os.Getenv("POLL_PERIOD")
-	if duration, err := time.ParseDuration(durationString); err != nil {
+	if cfg.PollerID == "" {
+		cfg.PollerID = defaultClaimant()
+	}
+
+	if err := cfg.validate(); err != nil {
+		return config{}, fmt.Errorf("validate config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (cfg *config) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func (cfg *config) readEnv() error {
+	dispatch.LookupString("POLLER_ID", &cfg.PollerID)
+	dispatch.LookupString("REMINDER_URL", &cfg.ReminderURL)
+
+	return errors.Join(
+		cfg.Config.ReadEnv(),
+		dispatch.LookupDuration("POLL_PERIOD", &cfg.PollPeriod),
+		dispatch.LookupDuration("STREAM_IDLE_TIMEOUT", &cfg.StreamIdleTimeout),
+		dispatch.LookupBool("STREAM", &cfg.Stream),
+	)
+}
+
+func (cfg config) validate() error {
+	return errors.Join(
+		cfg.Config.Validate(),
+		dispatch.ValidateURL("reminder url", cfg.ReminderURL),
+		dispatch.ValidatePositive("poll period", cfg.PollPeriod),
+		dispatch.ValidatePositive("stream idle timeout", cfg.StreamIdleTimeout),
+	)
+}
+
+// defaultClaimant returns the name the poller claims reminds with unless
+// POLLER_ID is set. Pollers running at once must use distinct names.
+func defaultClaimant() string {
+	hostname, err := os.Hostname()
+	if err != nil {
 		log.Warn().
 			Err(err).
-			Str("duration string", durationString).
-			Msg("Warning: could not parse env `POLL_PERIOD`, using default value: 1m")
-		ticker = time.NewTicker(1 * time.Minute)
-	} else {
-		log.Info().
-			Str("duration string", durationString).
-			Str("duration", duration.String()).
-			Msg("Duration successfully parsed")
-		ticker = time.NewTicker(duration)
-	}
-
-	return ticker
+			Msg("Warning: could not get hostname, using default poller id: poller")
+		return "poller"
+	}
+	return hostname
 }
 
 func setupGracefulShutdown(
@@ -2,10 +2,15 @@ module github.com/andrey-dru-me1/mattermost-reminder-bot/poller
 
 go 1.23.1
 
-require github.com/rs/zerolog v1.33.0
+require (
+	github.com/andrey-dru-me1/mattermost-reminder-bot/reminder v0.0.0
+	github.com/rs/zerolog v1.33.0
+)
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 )
+
+replace github.com/andrey-dru-me1/mattermost-reminder-bot/reminder => ../reminder
@@ -5,72 +5,116 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dispatch"
 	"github.com/rs/zerolog/log"
 )
 
-func sendRemindToMM(
-	c context.Context,
-	remind remind,
-) (*http.Response, error) {
-	logger := log.With().Interface("reminder", remind).Logger()
+// client talks to the reminder service. It tracks the deliveries of the
+// dispatcher through the service API.
+type client struct {
+	http        *http.Client
+	stream      *http.Client
+	reminderURL string
+}
+
+func newClient(cfg config) (*client, error) {
+	httpClient, err := cfg.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	// The stream is long-lived, it is watched by the idle timeout instead.
+	streamClient := *httpClient
+	streamClient.Timeout = 0
+
+	return &client{
+		http:        httpClient,
+		stream:      &streamClient,
+		reminderURL: cfg.ReminderURL,
+	}, nil
+}
 
-	type message struct {
-		Channel string `json:"channel"`
-		Message string `json:"text"`
+func (cl *client) reminderEndpoint(elem ...string) (string, error) {
+	endpoint, err := url.JoinPath(cl.reminderURL, elem...)
+	if err != nil {
+		return "", fmt.Errorf("build reminder service url: %w", err)
 	}
+	return endpoint, nil
+}
+
+func (cl *client) Complete(c context.Context, reminder dispatch.Remind) error {
+	logger := log.With().Interface("reminder", reminder).Logger()
 
-	rem := message{
-		Channel: remind.Channel,
-		Message: remind.Message,
+	jsonStr, err := json.Marshal([]string{reminder.OccurrenceID})
+	if err != nil {
+		return fmt.Errorf("parse json from occurrence id: %w", err)
 	}
 
-	jsonStr, err := json.Marshal(rem)
+	endpoint, err := cl.reminderEndpoint("reminders", "triggered")
 	if err != nil {
-		return nil, fmt.Errorf("parse json from reminder: %w", err)
+		return err
 	}
 
 	req, err := http.NewRequestWithContext(
 		c,
 		"POST",
-		"http://test_mm:8065/hooks/"+remind.Webhook,
+		endpoint,
 		bytes.NewBuffer(jsonStr),
 	)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"create request to a mattermost webhook: %w",
-			err,
-		)
+		return fmt.Errorf("create request to a reminder service: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := cl.http.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("send message to a mattermost webhook: %w", err)
+		return fmt.Errorf("send message to a reminder service: %w", err)
 	}
+	defer resp.Body.Close()
 
-	logger.Info().
-		Bytes(reqBody, jsonStr).
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("complete remind: unexpected status: %s", resp.Status)
+	}
+
+	logger.Info().Bytes(reqBody, jsonStr).
 		Interface(respStatus, resp.Status).
 		Interface(respHeader, resp.Header).
-		Msg("Remind sent to mattermost")
+		Msg("Remind marked completed")
 
-	return resp, nil
+	return nil
 }
 
-func markRemindCompleted(c context.Context, reminder remind) error {
+func (cl *client) Fail(
+	c context.Context,
+	reminder dispatch.Remind,
+	reason error,
+) error {
 	logger := log.With().Interface("reminder", reminder).Logger()
 
-	jsonStr := []byte(fmt.Sprintf(
-		`[%d]`,
-		reminder.ID,
-	))
+	type failedRemind struct {
+		OccurrenceID string `json:"occurrence_id"`
+		Error        string `json:"error"`
+	}
+
+	jsonStr, err := json.Marshal([]failedRemind{
+		{OccurrenceID: reminder.OccurrenceID, Error: reason.Error()},
+	})
+	if err != nil {
+		return fmt.Errorf("parse json from failed remind: %w", err)
+	}
+
+	endpoint, err := cl.reminderEndpoint("reminders", "dead")
+	if err != nil {
+		return err
+	}
 
 	req, err := http.NewRequestWithContext(
 		c,
 		"POST",
-		"http://reminder:8080/reminders/triggered",
+		endpoint,
 		bytes.NewBuffer(jsonStr),
 	)
 	if err != nil {
@@ -78,16 +122,133 @@ func markRemindCompleted(c context.Context, reminder remind) error {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := cl.http.Do(req)
 	if err != nil {
 		return fmt.Errorf("send message to a reminder service: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("report failed remind: unexpected status: %s", resp.Status)
+	}
+
 	logger.Info().Bytes(reqBody, jsonStr).
 		Interface(respStatus, resp.Status).
 		Interface(respHeader, resp.Header).
-		Msg("Remind marked completed")
+		Msg("Remind marked failed")
 
 	return nil
 }
+
+func (cl *client) ReportDelivery(c context.Context, d dispatch.Delivery) error {
+	logger := log.With().Interface("delivery", d).Logger()
+
+	jsonStr, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("parse json from delivery: %w", err)
+	}
+
+	endpoint, err := cl.reminderEndpoint("reminders", "deliveries")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(
+		c,
+		"POST",
+		endpoint,
+		bytes.NewBuffer(jsonStr),
+	)
+	if err != nil {
+		return fmt.Errorf("create request to a reminder service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := cl.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("send delivery to a reminder service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("report delivery: unexpected status: %s", resp.Status)
+	}
+
+	logger.Info().
+		Interface(respStatus, resp.Status).
+		Msg("Delivery reported")
+
+	return nil
+}
+
+// claimReminds fetches the reminds due for delivery leasing them to the
+// claimant.
+func (cl *client) claimReminds(
+	c context.Context,
+	claimant string,
+) ([]dispatch.Remind, error) {
+	endpoint, err := cl.reminderEndpoint("reminders", "triggered")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		c,
+		"GET",
+		endpoint+"?claimant="+url.QueryEscape(claimant),
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create request to a reminder service: %w", err)
+	}
+
+	resp, err := cl.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get reminds from a reminder service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("claim reminds: unexpected status: %s", resp.Status)
+	}
+
+	var reminders []dispatch.Remind
+	if err := json.NewDecoder(resp.Body).Decode(&reminders); err != nil {
+		return nil, fmt.Errorf("parse reminds: %w", err)
+	}
+	return reminders, nil
+}
+
+// openRemindsStream connects to the stream of triggered reminds. The stream is
+// closed by canceling the context or closing the returned body.
+func (cl *client) openRemindsStream(
+	c context.Context,
+	claimant string,
+) (io.ReadCloser, error) {
+	endpoint, err := cl.reminderEndpoint("reminders", "triggered", "stream")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(
+		c,
+		"GET",
+		endpoint+"?claimant="+url.QueryEscape(claimant),
+		nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create request to a reminder service: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := cl.stream.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("connect to reminds stream: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("connect to reminds stream: unexpected status: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
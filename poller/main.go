@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -12,7 +13,22 @@ import (
 func main() {
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 
-	ticker := setupTicker()
+	cfg, err := loadConfig()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+	log.Info().Interface("config", cfg).Msg("Configuration loaded")
+
+	client, err := newClient(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not set up http client")
+	}
+	processor, err := newProcessor(cfg, client)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not set up dispatcher")
+	}
+
+	ticker := time.NewTicker(time.Duration(cfg.PollPeriod))
 
 	ctx, cancelCtx := context.WithCancel(context.Background())
 	wg := &sync.WaitGroup{}
@@ -25,14 +41,25 @@ func main() {
 		}
 	}()
 
+	if cfg.Stream {
+		wg.Add(1)
+		go processor.streamReminds(ctx, wg)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			wg.Wait()
+			if err := processor.dispatcher.Close(); err != nil {
+				log.Err(err).Msg("Could not close journal")
+			}
 			log.Info().Msg("All goroutines finished, exiting")
 			return
 		case <-ticker.C:
-			if err := processReminds(ctx, wg); err != nil {
+			if processor.streaming.Load() {
+				continue
+			}
+			if err := processor.processReminds(ctx, wg); err != nil {
 				log.Err(err).Msg("Error processing reminds")
 			}
 		}
@@ -2,60 +2,47 @@ package main
 
 import (
 	"context"
-	"encoding/json"
-	"io"
-	"net/http"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dispatch"
 	"github.com/rs/zerolog/log"
 )
 
-func handleRemind(c context.Context, wg *sync.WaitGroup, reminder remind) {
-	defer wg.Done()
-	logger := log.With().Interface("reminder", reminder).Logger()
+// processor receives the reminds from the reminder service and hands them
+// out to the dispatcher.
+type processor struct {
+	client      *client
+	dispatcher  *dispatch.Dispatcher
+	claimant    string
+	reconnect   dispatch.RetryPolicy
+	idleTimeout time.Duration
+	streaming   atomic.Bool
+}
 
-	resp, err := sendRemindToMM(c, reminder)
+func newProcessor(cfg config, client *client) (*processor, error) {
+	dispatcher, err := dispatch.New(cfg.Config, client)
 	if err != nil {
-		logger.Error().Err(err).Msg("Could not send remind to mattermost")
-		return
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		if err := markRemindCompleted(c, reminder); err != nil {
-			logger.Error().Err(err).Msg("Could not mark remind completed")
-		}
-	} else {
-		logger.Error().
-			Interface(respStatus, resp.Status).
-			Interface(respHeader, resp.Header).
-			Msg("Failed sending message to mattermost")
-	}
+	return &processor{
+		client:      client,
+		dispatcher:  dispatcher,
+		claimant:    cfg.PollerID,
+		reconnect:   cfg.RetryPolicy(),
+		idleTimeout: time.Duration(cfg.StreamIdleTimeout),
+	}, nil
 }
 
-func processReminds(c context.Context, wg *sync.WaitGroup) error {
-	resp, err := http.Get("http://reminder:8080/reminders/triggered")
-	if err != nil {
-		return err
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	resp.Body.Close()
+func (p *processor) processReminds(c context.Context, wg *sync.WaitGroup) error {
+	reminders, err := p.client.claimReminds(c, p.claimant)
 	if err != nil {
 		return err
 	}
-
-	var reminders []remind
-
-	if err := json.Unmarshal(body, &reminders); err != nil {
-		return err
-	}
 	log.Info().Interface("reminders", reminders).Msg("Got reminds")
 
-	for _, reminder := range reminders {
-		wg.Add(1)
-		go handleRemind(c, wg, reminder)
-	}
-
+	p.dispatcher.Dispatch(c, wg, reminders)
 	return nil
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dispatch"
+	"github.com/rs/zerolog/log"
+)
+
+// maxEventSize bounds a single event of the reminds stream.
+const maxEventSize = 16 << 20
+
+// streamReminds consumes the stream of triggered reminds, reconnecting with a
+// backoff whenever it breaks. Until the stream is connected, reminds are
+// polled every poll period.
+func (p *processor) streamReminds(c context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	for attempt := 1; ; attempt++ {
+		connected, err := p.consumeStream(c, wg)
+		p.streaming.Store(false)
+		if c.Err() != nil {
+			return
+		}
+		if connected {
+			attempt = 1
+		}
+
+		delay := p.reconnect.Backoff(attempt)
+		log.Warn().
+			Err(err).
+			Str("delay", delay.String()).
+			Msg("Reminds stream disconnected, polling until reconnected")
+
+		select {
+		case <-c.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// consumeStream reads the stream until it breaks or stays silent for longer
+// than the idle timeout. It reports whether the stream was connected.
+func (p *processor) consumeStream(c context.Context, wg *sync.WaitGroup) (bool, error) {
+	ctx, cancel := context.WithCancel(c)
+	defer cancel()
+
+	body, err := p.client.openRemindsStream(ctx, p.claimant)
+	if err != nil {
+		return false, err
+	}
+	defer body.Close()
+
+	p.streaming.Store(true)
+	log.Info().Msg("Connected to reminds stream")
+
+	idle := time.AfterFunc(p.idleTimeout, cancel)
+	defer idle.Stop()
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxEventSize)
+
+	var event string
+	var data strings.Builder
+	for scanner.Scan() {
+		idle.Reset(p.idleTimeout)
+
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event == "reminds" {
+				var reminders []dispatch.Remind
+				if err := json.Unmarshal([]byte(data.String()), &reminders); err != nil {
+					return true, fmt.Errorf("parse reminds: %w", err)
+				}
+				log.Info().Interface("reminders", reminders).Msg("Got reminds")
+				p.dispatcher.Dispatch(c, wg, reminders)
+			}
+			event = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// A comment, sent as a heartbeat.
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return true, fmt.Errorf("read reminds stream: %w", err)
+	}
+	return true, errors.New("reminds stream closed")
+}
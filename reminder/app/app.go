@@ -4,8 +4,10 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dispatch"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/rman"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
@@ -20,10 +22,27 @@ type TriggeredReminder struct {
 	Complete chan bool
 }
 
+// defaultRemindLease is how long a claimed remind is reserved for the poller
+// that claimed it.
+const defaultRemindLease = time.Minute
+
+// defaultReconcileInterval is how often the schedule is reconciled with the
+// reminders stored in the database.
+const defaultReconcileInterval = 5 * time.Minute
+
 type Application struct {
 	Db              *sql.DB
 	RemindManager   rman.RemindManager
 	DefaultLocation *time.Location
+	RemindLease     time.Duration
+	// ReconcileInterval is zero if the periodic reconciliation is disabled.
+	ReconcileInterval time.Duration
+	// ScheduleMu is held for writing while the schedule is reconciled, the
+	// changes of the reminders hold it for reading.
+	ScheduleMu sync.RWMutex
+	// Dispatch configures the embedded dispatcher, it is nil unless the
+	// dispatcher is enabled.
+	Dispatch *dispatch.Config
 }
 
 func SetupApplication() (*Application, error) {
@@ -41,19 +60,78 @@ func SetupApplication() (*Application, error) {
 		loc = time.UTC
 	}
 
-	rman := rman.New(db, loc)
+	rman, err := setupRemindManager(db, loc)
+	if err != nil {
+		return nil, fmt.Errorf("setup remind manager: %w", err)
+	}
 	err = setupRemindGenerator(db, rman)
 	if err != nil {
 		return nil, err
 	}
 
+	lease, err := time.ParseDuration(os.Getenv("REMIND_LEASE"))
+	if err != nil || lease <= 0 {
+		lease = defaultRemindLease
+	}
+
+	reconcileInterval := defaultReconcileInterval
+	if s := os.Getenv("RECONCILE_INTERVAL"); s != "" {
+		reconcileInterval, err = time.ParseDuration(s)
+		if err != nil || reconcileInterval < 0 {
+			return nil, fmt.Errorf("invalid reconcile interval '%s'", s)
+		}
+	}
+
+	dispatchCfg, err := setupDispatch()
+	if err != nil {
+		return nil, fmt.Errorf("setup embedded dispatcher: %w", err)
+	}
+
 	return &Application{
-		Db:              db,
-		RemindManager:   rman,
-		DefaultLocation: loc,
+		Db:                db,
+		RemindManager:     rman,
+		DefaultLocation:   loc,
+		RemindLease:       lease,
+		ReconcileInterval: reconcileInterval,
+		Dispatch:          dispatchCfg,
 	}, nil
 }
 
+// setupRemindManager creates the remind manager selected by REMIND_MANAGER.
+// The in-memory one is used by default, the database one allows running
+// several instances of the service.
+func setupRemindManager(
+	db *sql.DB,
+	loc *time.Location,
+) (rman.RemindManager, error) {
+	switch kind := os.Getenv("REMIND_MANAGER"); kind {
+	case "", "memory":
+		return rman.New(db, loc), nil
+	case "database":
+		return rman.NewDatabase(db, loc), nil
+	default:
+		return nil, fmt.Errorf("unknown remind manager: %q", kind)
+	}
+}
+
+// setupDispatch reads the embedded dispatcher options if EMBEDDED_DISPATCHER
+// is enabled. They are named the same as the options of the poller.
+func setupDispatch() (*dispatch.Config, error) {
+	var enabled bool
+	if err := dispatch.LookupBool("EMBEDDED_DISPATCHER", &enabled); err != nil || !enabled {
+		return nil, err
+	}
+
+	cfg := dispatch.DefaultConfig()
+	if err := cfg.ReadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
 func runMigrations(db *sql.DB) error {
 	driver, err := mmysql.WithInstance(db, &mmysql.Config{})
 	if err != nil {
@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Reconcile forces the reconciliation of the schedule with the database and
+// responds with the reminders that were added, rescheduled or cancelled.
+func Reconcile(c *gin.Context) {
+	app := c.MustGet("app").(*app.Application)
+
+	result, err := services.ReconcileReminders(app)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
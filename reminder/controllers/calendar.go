@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ImportCalendar excludes the days of the events of the .ics file in the body
+// by the calendar named in the path. The calendar belongs to the channel in
+// the query unless it is global.
+func ImportCalendar(c *gin.Context) {
+	app, err := extractApp(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel := c.Query("channel")
+	global, _ := strconv.ParseBool(c.Query("global"))
+	if channel == "" && !global {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "either channel or global must be set"})
+		return
+	}
+
+	imported, err := services.ImportCalendar(app, c.Param("name"), channel, global, c.Request.Body)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidCalendar) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"imported": imported})
+}
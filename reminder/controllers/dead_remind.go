@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dtos"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/services"
+	"github.com/gin-gonic/gin"
+)
+
+func GetDeadReminds(c *gin.Context) {
+	app := c.MustGet("app").(*app.Application)
+	reminds := services.GetDeadReminds(app)
+	if len(reminds) > 0 {
+		c.IndentedJSON(http.StatusOK, reminds)
+	} else {
+		c.JSON(http.StatusOK, [0]int{})
+	}
+}
+
+// FailReminds moves reminds that could not be delivered to the dead letters.
+// The request is an array of occurrence IDs with the failure reasons.
+func FailReminds(c *gin.Context) {
+	app := c.MustGet("app").(*app.Application)
+
+	var failures []dtos.FailedRemindDTO
+	if err := c.BindJSON(&failures); err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf(
+					"Request should consist of a failed remind array: %s",
+					err.Error(),
+				),
+			},
+		)
+		return
+	}
+
+	if unknown := services.FailReminds(app, failures); len(unknown) > 0 {
+		c.JSON(http.StatusOK, gin.H{"unknown": unknown})
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func bindOccurrenceIDs(c *gin.Context) ([]string, bool) {
+	var ids []string
+	if err := c.BindJSON(&ids); err != nil {
+		c.JSON(
+			http.StatusBadRequest,
+			gin.H{
+				"error": fmt.Sprintf(
+					"Request should consist of an occurrence id array: %s",
+					err.Error(),
+				),
+			},
+		)
+		return nil, false
+	}
+	return ids, true
+}
+
+func ReplayReminds(c *gin.Context) {
+	app := c.MustGet("app").(*app.Application)
+
+	ids, ok := bindOccurrenceIDs(c)
+	if !ok {
+		return
+	}
+
+	services.ReplayReminds(app, ids)
+	c.Status(http.StatusOK)
+}
+
+func DiscardDeadReminds(c *gin.Context) {
+	app := c.MustGet("app").(*app.Application)
+
+	ids, ok := bindOccurrenceIDs(c)
+	if !ok {
+		return
+	}
+
+	services.DiscardDeadReminds(app, ids)
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dtos"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/services"
+	"github.com/gin-gonic/gin"
+)
+
+func ReportDelivery(c *gin.Context) {
+	app, err := extractApp(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request dtos.DeliveryDTO
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := services.ReportDelivery(app, request)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Delivery reported successfully",
+		"id":      id,
+	})
+}
+
+func GetDeliveries(c *gin.Context) {
+	app, err := extractApp(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	reminderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var limit int
+	if limitString, ok := c.GetQuery("limit"); ok {
+		if limit, err = strconv.Atoi(limitString); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	deliveries, err := services.GetDeliveries(app, reminderID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(deliveries) > 0 {
+		c.IndentedJSON(http.StatusOK, deliveries)
+	} else {
+		c.JSON(http.StatusOK, [0]int{})
+	}
+}
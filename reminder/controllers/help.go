@@ -5,9 +5,25 @@ func usage() string {
 		"Commands:\n\n" +
 
 		"- `help,h [cron,location,webhook]` - show more descriptive help message about specified command\n" +
-		"- `add,create NAME CRON_RULE MESSAGE` - creates new reminder\n" +
-		"- `list,ls` - lists all reminders\n" +
+		"- `add,create NAME CRON_RULE MESSAGE [--catch-up all|latest|skip] [--overlap queue|coalesce|drop]` - creates new reminder. `--catch-up` sets which occurrences missed during a downtime are sent after it (`skip` by default, `latest` for one-shot reminders). `--overlap` sets what happens to a new remind while the previous one is not delivered yet (`coalesce` by default)\n" +
+		"- `add,create NAME at TIME|in DURATION MESSAGE` - creates a one-shot reminder sent at `TIME` (`YYYY-MM-DD HH:MM` or `HH:MM`) or after `DURATION` (`2h30m`), it is archived after that\n" +
+		"- `CRON_RULE` may also be an interval from a start date, like `every 2w from 2026-11-04 10:00` or `every 10d from 2026-11-04` (`h`, `d` or `w`)\n" +
+		"- `CRON_RULE` may also be an RFC 5545 recurrence rule, like `RRULE:FREQ=MONTHLY;BYDAY=2TU;BYHOUR=10`, optionally with `DTSTART:` before it and `EXDATE:` after it\n" +
+		"- `CRON_RULE` may also be a phrase in English or Russian, like `every weekday at 9:30`, `every 2nd tuesday at noon`, `tomorrow at 10` or `каждый понедельник в 10`, the reply shows the rule it was interpreted as. `every 2nd tuesday` is the second Tuesday of every month, not every other one\n" +
+		"- `list,ls [--all]` - lists the reminders with their rules in words and next runs, `--all` lists the archived ones as well\n" +
+		"- `next ID|CRON_RULE [N]` - previews N (5 by default) next runs of the reminder with id `ID` or of a rule before creating a reminder with it\n" +
 		"- `delete,del,remove,rm ID...` - deletes a reminders with ID... identifiers\n" +
+		"- `edit,update ID [--name NAME] [--rule RULE] [--message MESSAGE]` - changes the reminder with id `ID` and reschedules it by the new rule, which may be of any kind `add` takes\n" +
+		"- `pause ID... [until DATE]` - pauses the reminders until `DATE` (`YYYY-MM-DD` or `YYYY-MM-DD HH:MM`) or until they are resumed\n" +
+		"- `resume ID...` - resumes the paused reminders, the runs skipped during the pause are not sent\n" +
+		"- `snooze ID DURATION|TIME` - sends the reminder with id `ID` once more after `DURATION` (`15m`, `2h30m`) or at `TIME` (`14:00`, `YYYY-MM-DD HH:MM`) without changing its schedule\n" +
+		"- `add` and `edit` take `--calendar NAME` to skip the dates of the calendar and `--on-holiday shift` to send such runs on the next working day instead, the one that is neither a calendar date nor a weekend day of the calendar\n" +
+		"- `add` takes `--starts DATE` and `--ends DATE` to send the reminder only between the dates, `--times N` to send it `N` times at most and `--notify-finish` to be told when it has finished\n" +
+		"- `calendar add NAME DATE... [--global]` - adds dates (`YYYY-MM-DD` or `YYYY-MM-DD..YYYY-MM-DD`) to the exclusion calendar of the channel, or a global one\n" +
+		"- `calendar rm NAME [DATE...]` - removes the dates from the calendar, or deletes it with no dates given\n" +
+		"- `calendar weekend NAME DAYS|none [--global]` - sets the weekend days (`fri,sat`, `sat,sun` by default) runs shifted by the calendar are not moved to\n" +
+		"- `calendar list [NAME]` - lists the calendars, or the dates of the calendar\n" +
+		"- `history,hist ID [N]` - shows N (10 by default) latest delivery attempts of the reminder with id `ID`\n" +
 		"- `timezone,tz LOCATION` - updates channel timezone\n" +
 		"- `timezone,tz` - shows current location\n" +
 		"- `wh,webhook WEBHOOK` - binds a `WEBHOOK` to the user. After this, the reminder could send messages to any chat the user can\n" +
@@ -1,6 +1,9 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -23,16 +26,20 @@ func CreateReminder(c *gin.Context) {
 		return
 	}
 
-	id, err := services.CreateReminder(app, request)
+	id, nextRun, err := services.CreateReminder(app, request)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message": "Reminder created successfully",
 		"id":      id,
-	})
+	}
+	if nextRun.IsZero() {
+		response["warning"] = "The rule has no future runs, the reminder is archived"
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 func GetReminders(c *gin.Context) {
@@ -51,6 +58,114 @@ func GetReminders(c *gin.Context) {
 	c.IndentedJSON(http.StatusOK, reminders)
 }
 
+// EditReminder changes the fields present in the body and reschedules the
+// reminder. If the body has a channel, the reminder must belong to it.
+func EditReminder(c *gin.Context) {
+	app, err := extractApp(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	reminderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request dtos.ReminderPatchDTO
+	if err := c.BindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reminder, _, err := services.EditReminder(app, reminderID, request)
+	if err != nil {
+		c.JSON(reminderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, reminder)
+}
+
+// PauseReminder pauses the reminder until the time in the body, indefinitely
+// if the body has none. If the body has a channel, the reminder must belong
+// to it.
+func PauseReminder(c *gin.Context) {
+	app, err := extractApp(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	reminderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request dtos.PauseDTO
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var until sql.NullTime
+	if request.Until != nil {
+		until = sql.NullTime{Time: *request.Until, Valid: true}
+	}
+	reminder, err := services.PauseReminder(app, reminderID, until, request.Channel)
+	if err != nil {
+		c.JSON(reminderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, reminder)
+}
+
+// ResumeReminder resumes the paused reminder. If the body has a channel, the
+// reminder must belong to it.
+func ResumeReminder(c *gin.Context) {
+	app, err := extractApp(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	reminderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request dtos.PauseDTO
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reminder, _, err := services.ResumeReminder(app, reminderID, request.Channel)
+	if err != nil {
+		c.JSON(reminderErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, reminder)
+}
+
+// reminderErrorStatus maps the errors of managing a reminder to the status
+// codes.
+func reminderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidAccess):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func DeleteReminder(c *gin.Context) {
 	app, err := extractApp(c)
 	if err != nil {
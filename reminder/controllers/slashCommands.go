@@ -14,17 +14,6 @@ import (
 	"github.com/google/shlex"
 )
 
-func mmReminderCreate(
-	app *app.Application,
-	req dtos.MMRequest,
-	tokens []string,
-) (string, error) {
-	if err := services.MMReminderCreate(app, req, tokens); err != nil {
-		return "", err
-	}
-	return "Reminder successfully created", nil
-}
-
 func mmReminderTimeZone(
 	app *app.Application,
 	req dtos.MMRequest,
@@ -33,11 +22,7 @@ func mmReminderTimeZone(
 	if len(tokens) <= 1 {
 		return services.MMReminderTimeZoneGet(app, req), nil
 	}
-	str, err := services.MMReminderTimeZoneSet(app, req, tokens)
-	if err != nil {
-		return "", err
-	}
-	return fmt.Sprintf("Time zone set to %s", str), nil
+	return services.MMReminderTimeZoneSet(app, req, tokens)
 }
 
 func authorize(c *gin.Context) error {
@@ -66,11 +51,25 @@ func processCommands(
 	if len(tokens) > 0 && strings.EqualFold(req.Command, "/reminder") {
 		switch tokens[0] {
 		case "add", "create":
-			str, err = mmReminderCreate(app, req, tokens)
+			str, err = services.MMReminderCreate(app, req, tokens)
 		case "list", "ls":
-			str, err = services.MMReminderList(app, req)
+			str, err = services.MMReminderList(app, req, tokens)
 		case "delete", "del", "remove", "rm":
 			str, err = services.MMReminderDelete(app, req, tokens)
+		case "edit", "update":
+			str, err = services.MMReminderEdit(app, req, tokens)
+		case "pause":
+			str, err = services.MMReminderPause(app, req, tokens)
+		case "resume":
+			str, err = services.MMReminderResume(app, req, tokens)
+		case "snooze":
+			str, err = services.MMReminderSnooze(app, req, tokens)
+		case "next":
+			str, err = services.MMReminderNext(app, req, tokens)
+		case "calendar", "cal":
+			str, err = services.MMReminderCalendar(app, req, tokens)
+		case "history", "hist":
+			str, err = services.MMReminderHistory(app, req, tokens)
 		case "timezone", "tz":
 			str, err = mmReminderTimeZone(app, req, tokens)
 		case "wh", "webhook":
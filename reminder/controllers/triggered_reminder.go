@@ -2,16 +2,74 @@ package controllers
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/services"
 	"github.com/gin-gonic/gin"
 )
 
+// maxTriggeredWait bounds how long a long-poll request waits for reminds.
+const maxTriggeredWait = time.Minute
+
+// streamHeartbeat is how often an idle stream is sent a heartbeat. Claiming is
+// retried on every heartbeat, so reminds whose lease expired are resent.
+const streamHeartbeat = 15 * time.Second
+
+// claimParams reads the claimant and the lease of a claim request. The
+// claimant defaults to the client IP and the lease to the REMIND_LEASE
+// setting.
+func claimParams(c *gin.Context) (string, time.Duration, bool) {
+	claimant := c.DefaultQuery("claimant", c.ClientIP())
+	var lease time.Duration
+	if leaseString, ok := c.GetQuery("lease"); ok {
+		var err error
+		if lease, err = time.ParseDuration(leaseString); err != nil || lease <= 0 {
+			c.JSON(
+				http.StatusBadRequest,
+				gin.H{"error": fmt.Sprintf("Invalid lease '%s'", leaseString)},
+			)
+			return "", 0, false
+		}
+	}
+	return claimant, lease, true
+}
+
+// GetTriggeredReminders claims the reminds ready for delivery. Claimed reminds
+// are not returned to anyone until the lease expires, so several pollers can
+// run at once. With the wait parameter the request is held until reminds are
+// triggered or the wait runs out.
 func GetTriggeredReminders(c *gin.Context) {
 	app := c.MustGet("app").(*app.Application)
-	reminds := services.GetReminds(app)
+
+	claimant, lease, ok := claimParams(c)
+	if !ok {
+		return
+	}
+
+	var wait time.Duration
+	if waitString, ok := c.GetQuery("wait"); ok {
+		var err error
+		if wait, err = time.ParseDuration(waitString); err != nil || wait < 0 {
+			c.JSON(
+				http.StatusBadRequest,
+				gin.H{"error": fmt.Sprintf("Invalid wait '%s'", waitString)},
+			)
+			return
+		}
+		wait = min(wait, maxTriggeredWait)
+	}
+
+	var reminds []models.Remind
+	if wait > 0 {
+		reminds = services.WaitReminds(c.Request.Context(), app, claimant, lease, wait)
+	} else {
+		reminds = services.ClaimReminds(app, claimant, lease)
+	}
+
 	if len(reminds) > 0 {
 		c.JSON(http.StatusOK, reminds)
 	} else {
@@ -19,10 +77,55 @@ func GetTriggeredReminders(c *gin.Context) {
 	}
 }
 
+// StreamTriggeredReminders claims reminds as soon as they are triggered and
+// sends them as server-sent events named "reminds" until the client
+// disconnects.
+func StreamTriggeredReminders(c *gin.Context) {
+	app := c.MustGet("app").(*app.Application)
+
+	claimant, lease, ok := claimParams(c)
+	if !ok {
+		return
+	}
+
+	updates, cancel := services.SubscribeReminds(app)
+	defer cancel()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+	c.Stream(func(w io.Writer) bool {
+		if reminds := services.ClaimReminds(app, claimant, lease); len(reminds) > 0 {
+			c.SSEvent("reminds", reminds)
+			return true
+		}
+
+		select {
+		case <-updates:
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return false
+			}
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
+
+// CompleteReminds acknowledges delivered reminds. The request is an array of
+// occurrence IDs. Numeric elements are treated as reminder IDs and complete
+// every pending occurrence of the reminder. Completing reminds that are
+// completed already succeeds, so a delivery may be acknowledged again.
 func CompleteReminds(c *gin.Context) {
 	app := c.MustGet("app").(*app.Application)
 
-	var ids []int64
+	var ids []any
 	if err := c.BindJSON(&ids); err != nil {
 		c.JSON(
 			http.StatusBadRequest,
@@ -36,6 +139,24 @@ func CompleteReminds(c *gin.Context) {
 		return
 	}
 
-	services.CompleteReminds(app, ids)
+	var occurrenceIDs []string
+	var reminderIDs []int64
+	for _, id := range ids {
+		switch id := id.(type) {
+		case string:
+			occurrenceIDs = append(occurrenceIDs, id)
+		case float64:
+			reminderIDs = append(reminderIDs, int64(id))
+		default:
+			c.JSON(
+				http.StatusBadRequest,
+				gin.H{"error": fmt.Sprintf("Invalid id: %v", id)},
+			)
+			return
+		}
+	}
+
+	services.CompleteReminds(app, occurrenceIDs)
+	services.CompleteRemindsOfReminders(app, reminderIDs)
 	c.Status(http.StatusOK)
 }
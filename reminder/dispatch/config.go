@@ -0,0 +1,181 @@
+package dispatch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Duration is a time.Duration read from a config file as a string with unit
+// suffixes, e.g. "2h45m".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+type TLSConfig struct {
+	CAFile             string `json:"ca_file"`
+	CertFile           string `json:"cert_file"`
+	KeyFile            string `json:"key_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+}
+
+// Config holds the options of delivering reminds to Mattermost shared by the
+// poller and the dispatcher embedded into the reminder service.
+type Config struct {
+	MattermostURL    string    `json:"mattermost_url"`
+	RequestTimeout   Duration  `json:"request_timeout"`
+	MaxConcurrency   int       `json:"max_concurrency"`
+	RetryMaxAttempts int       `json:"retry_max_attempts"`
+	RetryBaseDelay   Duration  `json:"retry_base_delay"`
+	RetryMaxDelay    Duration  `json:"retry_max_delay"`
+	TLS              TLSConfig `json:"tls"`
+	// JournalFile keeps the idempotency keys of the posted reminds, the
+	// journal is disabled if it is empty.
+	JournalFile      string   `json:"journal_file"`
+	JournalRetention Duration `json:"journal_retention"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		RequestTimeout:   Duration(10 * time.Second),
+		MaxConcurrency:   10,
+		RetryMaxAttempts: 5,
+		RetryBaseDelay:   Duration(time.Second),
+		RetryMaxDelay:    Duration(30 * time.Second),
+		JournalRetention: Duration(7 * 24 * time.Hour),
+	}
+}
+
+// ReadEnv overrides the options set in the environment.
+func (cfg *Config) ReadEnv() error {
+	LookupString("MM_URL", &cfg.MattermostURL)
+	LookupString("TLS_CA_FILE", &cfg.TLS.CAFile)
+	LookupString("TLS_CERT_FILE", &cfg.TLS.CertFile)
+	LookupString("TLS_KEY_FILE", &cfg.TLS.KeyFile)
+	LookupString("JOURNAL_FILE", &cfg.JournalFile)
+
+	return errors.Join(
+		LookupDuration("REQUEST_TIMEOUT", &cfg.RequestTimeout),
+		LookupDuration("RETRY_BASE_DELAY", &cfg.RetryBaseDelay),
+		LookupDuration("RETRY_MAX_DELAY", &cfg.RetryMaxDelay),
+		LookupDuration("JOURNAL_RETENTION", &cfg.JournalRetention),
+		LookupInt("MAX_CONCURRENCY", &cfg.MaxConcurrency),
+		LookupInt("RETRY_MAX_ATTEMPTS", &cfg.RetryMaxAttempts),
+		LookupBool("TLS_INSECURE_SKIP_VERIFY", &cfg.TLS.InsecureSkipVerify),
+	)
+}
+
+func (cfg Config) Validate() error {
+	errs := []error{
+		ValidateURL("mattermost url", cfg.MattermostURL),
+		ValidatePositive("request timeout", cfg.RequestTimeout),
+		ValidatePositive("max concurrency", cfg.MaxConcurrency),
+		ValidatePositive("retry max attempts", cfg.RetryMaxAttempts),
+		ValidatePositive("retry base delay", cfg.RetryBaseDelay),
+		ValidatePositive("retry max delay", cfg.RetryMaxDelay),
+		ValidatePositive("journal retention", cfg.JournalRetention),
+	}
+	if cfg.RetryBaseDelay > cfg.RetryMaxDelay {
+		errs = append(errs, fmt.Errorf(
+			"retry base delay %s exceeds retry max delay %s",
+			cfg.RetryBaseDelay,
+			cfg.RetryMaxDelay,
+		))
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs = append(errs, errors.New("tls cert file and key file must be set together"))
+	}
+	return errors.Join(errs...)
+}
+
+func (cfg Config) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: cfg.RetryMaxAttempts,
+		BaseDelay:   time.Duration(cfg.RetryBaseDelay),
+		MaxDelay:    time.Duration(cfg.RetryMaxDelay),
+	}
+}
+
+// HTTPClient returns a client that gives up on requests lasting longer than
+// the request timeout and uses the configured TLS options.
+func (cfg Config) HTTPClient() (*http.Client, error) {
+	tlsCfg := &tls.Config{InsecureSkipVerify: cfg.TLS.InsecureSkipVerify}
+
+	if cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.TLS.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls key pair: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsCfg
+	transport.MaxIdleConnsPerHost = cfg.MaxConcurrency
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(cfg.RequestTimeout),
+	}, nil
+}
+
+func ValidateURL(name string, rawURL string) error {
+	if rawURL == "" {
+		return fmt.Errorf("%s is required", name)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", name, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%s must be an http or https url, got '%s'", name, rawURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%s has no host: '%s'", name, rawURL)
+	}
+	return nil
+}
+
+func ValidatePositive[T int | Duration](name string, value T) error {
+	if value <= 0 {
+		return fmt.Errorf("%s must be positive, got %v", name, value)
+	}
+	return nil
+}
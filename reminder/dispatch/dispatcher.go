@@ -0,0 +1,188 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Tracker records the progress of remind deliveries, either in the reminder
+// service itself or through its API.
+type Tracker interface {
+	ReportDelivery(c context.Context, delivery Delivery) error
+	Complete(c context.Context, remind Remind) error
+	Fail(c context.Context, remind Remind, reason error) error
+}
+
+// Dispatcher delivers reminds to Mattermost, at most MaxConcurrency at once,
+// retrying transient failures with a backoff. With a journal, a remind posted
+// already is only completed.
+type Dispatcher struct {
+	mattermost *Mattermost
+	tracker    Tracker
+	journal    *Journal
+	policy     RetryPolicy
+	slots      chan struct{}
+}
+
+func New(cfg Config, tracker Tracker) (*Dispatcher, error) {
+	client, err := cfg.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var journal *Journal
+	if cfg.JournalFile != "" {
+		journal, err = OpenJournal(cfg.JournalFile, time.Duration(cfg.JournalRetention))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Dispatcher{
+		mattermost: NewMattermost(client, cfg.MattermostURL),
+		tracker:    tracker,
+		journal:    journal,
+		policy:     cfg.RetryPolicy(),
+		slots:      make(chan struct{}, cfg.MaxConcurrency),
+	}, nil
+}
+
+// Close releases the journal. It must be called after the started deliveries
+// are finished.
+func (d *Dispatcher) Close() error {
+	if d.journal == nil {
+		return nil
+	}
+	return d.journal.Close()
+}
+
+// Dispatch starts delivering the reminds, waiting for a free slot when
+// MaxConcurrency reminds are being delivered already. Every started delivery
+// is added to wg.
+func (d *Dispatcher) Dispatch(c context.Context, wg *sync.WaitGroup, reminds []Remind) {
+	for _, remind := range reminds {
+		select {
+		case d.slots <- struct{}{}:
+		case <-c.Done():
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-d.slots }()
+			d.deliver(c, remind)
+		}()
+	}
+}
+
+// deliver retries transient failures with a backoff. Reminds that failed
+// permanently or ran out of attempts are reported as failed.
+func (d *Dispatcher) deliver(c context.Context, remind Remind) {
+	logger := log.With().Interface("reminder", remind).Logger()
+
+	if d.posted(remind) {
+		logger.Info().Msg("Remind was posted already, marking it completed")
+		if err := d.tracker.Complete(c, remind); err != nil {
+			logger.Error().Err(err).Msg("Could not mark remind completed")
+		}
+		return
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := d.attempt(c, remind, attempt)
+		if err == nil {
+			d.record(remind)
+			if err := d.tracker.Complete(c, remind); err != nil {
+				logger.Error().Err(err).Msg("Could not mark remind completed")
+			}
+			return
+		}
+		if c.Err() != nil {
+			return
+		}
+
+		if IsPermanent(err) || attempt >= d.policy.MaxAttempts {
+			if err := d.tracker.Fail(c, remind, err); err != nil {
+				logger.Error().Err(err).Msg("Could not mark remind failed")
+			}
+			return
+		}
+
+		delay := d.policy.Backoff(attempt)
+		logger.Warn().
+			Err(err).
+			Int("attempt", attempt).
+			Str("delay", delay.String()).
+			Msg("Retrying to send remind")
+
+		select {
+		case <-c.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (d *Dispatcher) posted(remind Remind) bool {
+	return d.journal != nil && remind.IdempotencyKey != "" &&
+		d.journal.Posted(remind.IdempotencyKey)
+}
+
+func (d *Dispatcher) record(remind Remind) {
+	if d.journal == nil || remind.IdempotencyKey == "" {
+		return
+	}
+	if err := d.journal.Record(remind.IdempotencyKey); err != nil {
+		log.Error().Err(err).Interface("reminder", remind).Msg("Could not record posted remind")
+	}
+}
+
+// attempt sends the remind to Mattermost once and reports the outcome.
+func (d *Dispatcher) attempt(c context.Context, remind Remind, attempt int) error {
+	logger := log.With().Interface("reminder", remind).Int("attempt", attempt).Logger()
+
+	delivery := Delivery{
+		ReminderID:   remind.ReminderID,
+		OccurrenceID: remind.OccurrenceID,
+		ScheduledAt:  remind.ScheduledAt,
+		Attempt:      attempt,
+	}
+	defer func() {
+		if err := d.tracker.ReportDelivery(c, delivery); err != nil {
+			logger.Error().Err(err).Msg("Could not report delivery")
+		}
+	}()
+
+	resp, err := d.mattermost.Send(c, remind)
+	if err != nil {
+		delivery.Error = err.Error()
+		logger.Error().Err(err).Msg("Could not send remind to mattermost")
+		return err
+	}
+	defer resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	if resp.StatusCode == http.StatusOK {
+		deliveredAt := time.Now()
+		delivery.DeliveredAt = &deliveredAt
+		return nil
+	}
+
+	delivery.Error = resp.Status
+	logger.Error().
+		Interface("status", resp.Status).
+		Interface("response header", resp.Header).
+		Msg("Failed sending message to mattermost")
+
+	err = fmt.Errorf("mattermost responded with status %s", resp.Status)
+	if !IsRetryableStatus(resp.StatusCode) {
+		return PermanentError{err}
+	}
+	return err
+}
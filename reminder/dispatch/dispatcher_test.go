@@ -0,0 +1,200 @@
+package dispatch_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dispatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTracker struct {
+	mu         sync.Mutex
+	deliveries []dispatch.Delivery
+	completed  []string
+	failed     map[string]error
+}
+
+func (t *fakeTracker) ReportDelivery(_ context.Context, d dispatch.Delivery) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deliveries = append(t.deliveries, d)
+	return nil
+}
+
+func (t *fakeTracker) Complete(_ context.Context, remind dispatch.Remind) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.completed = append(t.completed, remind.OccurrenceID)
+	return nil
+}
+
+func (t *fakeTracker) Fail(_ context.Context, remind dispatch.Remind, reason error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.failed == nil {
+		t.failed = make(map[string]error)
+	}
+	t.failed[remind.OccurrenceID] = reason
+	return nil
+}
+
+// mattermost answers the webhook requests with the statuses in turn, the
+// last one is repeated.
+func mattermost(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/hooks/webhook", r.URL.Path)
+		call := int(calls.Add(1))
+		w.WriteHeader(statuses[min(call, len(statuses))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func testConfig(t *testing.T, url string) dispatch.Config {
+	cfg := dispatch.DefaultConfig()
+	cfg.MattermostURL = url
+	cfg.RetryMaxAttempts = 3
+	cfg.RetryBaseDelay = dispatch.Duration(time.Millisecond)
+	cfg.RetryMaxDelay = dispatch.Duration(time.Millisecond)
+	require.NoError(t, cfg.Validate())
+	return cfg
+}
+
+func dispatchRemind(t *testing.T, cfg dispatch.Config, tracker *fakeTracker) {
+	dispatcher, err := dispatch.New(cfg, tracker)
+	require.NoError(t, err)
+	defer dispatcher.Close()
+
+	wg := &sync.WaitGroup{}
+	dispatcher.Dispatch(context.Background(), wg, []dispatch.Remind{
+		{OccurrenceID: "occurrence", IdempotencyKey: "1@key", ReminderID: 1, Webhook: "webhook"},
+	})
+	wg.Wait()
+}
+
+func TestDispatcher(t *testing.T) {
+	t.Run("retries transient failures", func(t *testing.T) {
+		srv, calls := mattermost(t, http.StatusBadGateway, http.StatusOK)
+		tracker := &fakeTracker{}
+		dispatchRemind(t, testConfig(t, srv.URL), tracker)
+
+		assert.EqualValues(t, 2, calls.Load())
+		assert.Equal(t, []string{"occurrence"}, tracker.completed)
+		assert.Empty(t, tracker.failed)
+
+		require.Len(t, tracker.deliveries, 2)
+		assert.Equal(t, 1, tracker.deliveries[0].Attempt)
+		assert.Equal(t, http.StatusBadGateway, tracker.deliveries[0].StatusCode)
+		assert.Nil(t, tracker.deliveries[0].DeliveredAt)
+		assert.Equal(t, 2, tracker.deliveries[1].Attempt)
+		assert.NotNil(t, tracker.deliveries[1].DeliveredAt)
+	})
+
+	t.Run("fails permanently on client errors", func(t *testing.T) {
+		srv, calls := mattermost(t, http.StatusNotFound)
+		tracker := &fakeTracker{}
+		dispatchRemind(t, testConfig(t, srv.URL), tracker)
+
+		assert.EqualValues(t, 1, calls.Load())
+		assert.Empty(t, tracker.completed)
+		require.Contains(t, tracker.failed, "occurrence")
+		assert.True(t, dispatch.IsPermanent(tracker.failed["occurrence"]))
+	})
+
+	t.Run("fails after the last attempt", func(t *testing.T) {
+		srv, calls := mattermost(t, http.StatusServiceUnavailable)
+		tracker := &fakeTracker{}
+		dispatchRemind(t, testConfig(t, srv.URL), tracker)
+
+		assert.EqualValues(t, 3, calls.Load())
+		assert.Len(t, tracker.deliveries, 3)
+		require.Contains(t, tracker.failed, "occurrence")
+		assert.False(t, dispatch.IsPermanent(tracker.failed["occurrence"]))
+	})
+
+	t.Run("does not post a remind twice", func(t *testing.T) {
+		srv, calls := mattermost(t, http.StatusOK)
+		cfg := testConfig(t, srv.URL)
+		cfg.JournalFile = filepath.Join(t.TempDir(), "journal")
+
+		first := &fakeTracker{}
+		dispatchRemind(t, cfg, first)
+		// The remind is handed out again as if its completion was lost.
+		second := &fakeTracker{}
+		dispatchRemind(t, cfg, second)
+
+		assert.EqualValues(t, 1, calls.Load())
+		assert.Equal(t, []string{"occurrence"}, first.completed)
+		assert.Equal(t, []string{"occurrence"}, second.completed)
+		assert.Empty(t, second.deliveries)
+	})
+}
+
+func TestJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(
+		"%d expired\n%d kept\nmalformed\n",
+		time.Now().Add(-2*time.Hour).Unix(),
+		time.Now().Add(-time.Minute).Unix(),
+	)), 0o600))
+
+	journal, err := dispatch.OpenJournal(path, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, journal.Posted("expired"))
+	assert.True(t, journal.Posted("kept"))
+	assert.False(t, journal.Posted("new"))
+
+	require.NoError(t, journal.Record("new"))
+	require.NoError(t, journal.Close())
+
+	journal, err = dispatch.OpenJournal(path, time.Hour)
+	require.NoError(t, err)
+	defer journal.Close()
+	assert.True(t, journal.Posted("kept"))
+	assert.True(t, journal.Posted("new"))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "expired")
+	assert.NotContains(t, string(content), "malformed")
+}
+
+func TestBackoff(t *testing.T) {
+	policy := dispatch.RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	for attempt, max := range map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		5:  10 * time.Second,
+		40: 10 * time.Second,
+	} {
+		delay := policy.Backoff(attempt)
+		assert.GreaterOrEqual(t, delay, max/2)
+		assert.LessOrEqual(t, delay, max)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := dispatch.DefaultConfig()
+	assert.Error(t, cfg.Validate())
+
+	cfg.MattermostURL = "https://mattermost.example.com"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.TLS.CertFile = "cert.pem"
+	cfg.RetryBaseDelay = dispatch.Duration(time.Hour)
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "tls cert file and key file")
+	assert.ErrorContains(t, err, "retry base delay")
+}
@@ -0,0 +1,56 @@
+package dispatch
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// The Lookup functions set the value from the environment variable if it is
+// present and leave the value as is otherwise.
+
+func LookupString(key string, value *string) {
+	if s, ok := os.LookupEnv(key); ok {
+		*value = s
+	}
+}
+
+func LookupDuration(key string, value *Duration) error {
+	s, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("parse `%s`: %w", key, err)
+	}
+	*value = Duration(parsed)
+	return nil
+}
+
+func LookupInt(key string, value *int) error {
+	s, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	parsed, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("parse `%s`: %w", key, err)
+	}
+	*value = parsed
+	return nil
+}
+
+func LookupBool(key string, value *bool) error {
+	s, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(s)
+	if err != nil {
+		return fmt.Errorf("parse `%s`: %w", key, err)
+	}
+	*value = parsed
+	return nil
+}
@@ -0,0 +1,157 @@
+package dispatch
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Journal remembers the idempotency keys of the reminds posted to Mattermost,
+// so a remind whose completion was lost is not posted again when it is handed
+// out once more.
+type Journal struct {
+	mu        sync.Mutex
+	path      string
+	retention time.Duration
+	file      *os.File
+	posted    map[string]time.Time
+	// compactedAt is when the expired keys were last dropped from the file.
+	compactedAt time.Time
+}
+
+// OpenJournal loads the keys recorded in the file during the retention period
+// and appends the new ones to it. The file is created if it does not exist.
+func OpenJournal(path string, retention time.Duration) (*Journal, error) {
+	j := &Journal{
+		path:      path,
+		retention: retention,
+		posted:    make(map[string]time.Time),
+	}
+
+	if err := j.load(); err != nil {
+		return nil, fmt.Errorf("load journal %s: %w", path, err)
+	}
+	if err := j.compact(); err != nil {
+		return nil, fmt.Errorf("compact journal %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// load reads the lines of "<unix time> <key>" format skipping the expired
+// and the malformed ones, a malformed line is left by an interrupted write.
+func (j *Journal) load() error {
+	file, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	expired := time.Now().Add(-j.retention)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		unix, key, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || key == "" {
+			continue
+		}
+		seconds, err := strconv.ParseInt(unix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if postedAt := time.Unix(seconds, 0); postedAt.After(expired) {
+			j.posted[key] = postedAt
+		}
+	}
+	return scanner.Err()
+}
+
+// compact rewrites the file with the keys that are not expired yet and
+// reopens it for appending.
+func (j *Journal) compact() error {
+	expired := time.Now().Add(-j.retention)
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	writer := bufio.NewWriter(tmp)
+	for key, postedAt := range j.posted {
+		if !postedAt.After(expired) {
+			delete(j.posted, key)
+			continue
+		}
+		fmt.Fprintf(writer, "%d %s\n", postedAt.Unix(), key)
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return err
+	}
+
+	if j.file != nil {
+		j.file.Close()
+	}
+	j.file, err = os.OpenFile(j.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	j.compactedAt = time.Now()
+	return nil
+}
+
+// Posted reports whether the remind with the key was posted already.
+func (j *Journal) Posted(key string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	postedAt, ok := j.posted[key]
+	return ok && time.Since(postedAt) < j.retention
+}
+
+// Record stores the key of a posted remind on disk. The expired keys are
+// dropped from the file once per retention period.
+func (j *Journal) Record(key string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	if _, err := fmt.Fprintf(j.file, "%d %s\n", now.Unix(), key); err != nil {
+		return fmt.Errorf("record %s in journal: %w", key, err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("record %s in journal: %w", key, err)
+	}
+	j.posted[key] = now
+
+	if now.Sub(j.compactedAt) > j.retention {
+		if err := j.compact(); err != nil {
+			return fmt.Errorf("compact journal %s: %w", j.path, err)
+		}
+	}
+	return nil
+}
+
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
@@ -0,0 +1,75 @@
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Mattermost posts reminds to incoming webhooks of a Mattermost server.
+type Mattermost struct {
+	client  *http.Client
+	baseURL string
+}
+
+func NewMattermost(client *http.Client, baseURL string) *Mattermost {
+	return &Mattermost{client: client, baseURL: baseURL}
+}
+
+// Send posts the remind to the webhook of its owner. Errors that will not go
+// away on retry are PermanentError.
+func (mm *Mattermost) Send(c context.Context, remind Remind) (*http.Response, error) {
+	logger := log.With().Interface("reminder", remind).Logger()
+
+	type message struct {
+		Channel string `json:"channel"`
+		Message string `json:"text"`
+	}
+
+	rem := message{
+		Channel: remind.Channel,
+		Message: remind.Message,
+	}
+
+	jsonStr, err := json.Marshal(rem)
+	if err != nil {
+		return nil, PermanentError{fmt.Errorf("parse json from reminder: %w", err)}
+	}
+
+	webhook, err := url.JoinPath(mm.baseURL, "hooks", remind.Webhook)
+	if err != nil {
+		return nil, PermanentError{fmt.Errorf("build webhook url: %w", err)}
+	}
+
+	req, err := http.NewRequestWithContext(
+		c,
+		"POST",
+		webhook,
+		bytes.NewBuffer(jsonStr),
+	)
+	if err != nil {
+		return nil, PermanentError{fmt.Errorf(
+			"create request to a mattermost webhook: %w",
+			err,
+		)}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := mm.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send message to a mattermost webhook: %w", err)
+	}
+
+	logger.Info().
+		Bytes("request body", jsonStr).
+		Interface("status", resp.Status).
+		Interface("response header", resp.Header).
+		Msg("Remind sent to mattermost")
+
+	return resp, nil
+}
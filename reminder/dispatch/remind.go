@@ -0,0 +1,27 @@
+package dispatch
+
+import "time"
+
+// Remind is an occurrence of a reminder to be posted to Mattermost.
+type Remind struct {
+	OccurrenceID   string    `json:"occurrence_id"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	ScheduledAt    time.Time `json:"scheduled_at"`
+	ReminderID     int64     `json:"id"`
+	Name           string    `json:"name"`
+	Rule           string    `json:"rule"`
+	Channel        string    `json:"channel"`
+	Message        string    `json:"message"`
+	Webhook        string    `json:"webhook"`
+}
+
+// Delivery is the outcome of a single attempt to post a remind.
+type Delivery struct {
+	ReminderID   int64      `json:"reminder_id"`
+	OccurrenceID string     `json:"occurrence_id"`
+	ScheduledAt  time.Time  `json:"scheduled_at"`
+	Attempt      int        `json:"attempt"`
+	StatusCode   int        `json:"status_code"`
+	Error        string     `json:"error"`
+	DeliveredAt  *time.Time `json:"delivered_at"`
+}
@@ -0,0 +1,53 @@
+package dispatch
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Backoff returns the delay before the attempt following the given one. The
+// delay grows exponentially up to MaxDelay, its second half is randomized so
+// reminds failed at once are not retried at once.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.MaxDelay
+	if shift := attempt - 1; shift < 32 && p.BaseDelay<<shift < p.MaxDelay {
+		delay = p.BaseDelay << shift
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// PermanentError is a delivery failure that will not go away on retry, for
+// example a deleted webhook.
+type PermanentError struct {
+	Err error
+}
+
+func (e PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func IsPermanent(err error) bool {
+	var permanent PermanentError
+	return errors.As(err, &permanent)
+}
+
+// IsRetryableStatus reports whether a Mattermost response status denotes a
+// transient failure. Other non-successful statuses are permanent.
+func IsRetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout ||
+		code == http.StatusTooManyRequests ||
+		code >= http.StatusInternalServerError
+}
@@ -1,11 +1,71 @@
 package dtos
 
+import "time"
+
 type ReminderDTO struct {
 	Name    string `json:"name"`
 	Owner   string `json:"owner"`
 	Rule    string `json:"rule"`
 	Channel string `json:"channel"`
 	Message string `json:"message"`
+	CatchUp string `json:"catch_up"`
+	Overlap string `json:"overlap"`
+	// TimeZone overrides the time zone of the channel if set.
+	TimeZone string `json:"time_zone"`
+	// ScheduleType is either cron (default) or once, then the rule is a date
+	// and time in the reminder time zone.
+	ScheduleType string `json:"schedule_type"`
+	// Calendar is the name of the calendar of the dates the reminder is not
+	// sent on, HolidayPolicy is either skip (default) or shift.
+	Calendar      string `json:"calendar"`
+	HolidayPolicy string `json:"holiday_policy"`
+	// StartsAt and EndsAt bound the runs of the reminder if set,
+	// MaxOccurrences limits the amount of its runs unless it is zero. The
+	// owner is told when the reminder finishes if NotifyFinish is set.
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	MaxOccurrences int64      `json:"max_occurrences"`
+	NotifyFinish   bool       `json:"notify_finish"`
+}
+
+// ReminderPatchDTO lists the fields of a reminder to change, nil fields are
+// left as is.
+type ReminderPatchDTO struct {
+	Name    *string `json:"name"`
+	Rule    *string `json:"rule"`
+	Message *string `json:"message"`
+	// ScheduleType is the type of the new rule, detected from the rule if it
+	// is not set.
+	ScheduleType *string `json:"schedule_type"`
+	// Calendar is the name of the calendar, empty to use none.
+	Calendar      *string `json:"calendar"`
+	HolidayPolicy *string `json:"holiday_policy"`
+	// Channel the reminder is edited from, the reminder must belong to it if
+	// set.
+	Channel string `json:"channel"`
+}
+
+// PauseDTO pauses a reminder until the time, indefinitely if it is not set.
+type PauseDTO struct {
+	Until *time.Time `json:"until"`
+	// Channel the reminder is paused from, the reminder must belong to it if
+	// set.
+	Channel string `json:"channel"`
+}
+
+type DeliveryDTO struct {
+	ReminderID   int64      `json:"reminder_id"`
+	OccurrenceID string     `json:"occurrence_id"`
+	ScheduledAt  time.Time  `json:"scheduled_at"`
+	Attempt      int        `json:"attempt"`
+	StatusCode   int        `json:"status_code"`
+	Error        string     `json:"error"`
+	DeliveredAt  *time.Time `json:"delivered_at"`
+}
+
+type FailedRemindDTO struct {
+	OccurrenceID string `json:"occurrence_id"`
+	Error        string `json:"error"`
 }
 
 type UserDTO struct {
@@ -0,0 +1,130 @@
+// Package ics reads the days of the events of an iCalendar (.ics) file, e.g.
+// of the public holidays exported from a calendar application.
+package ics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+// maxEventDays bounds the amount of days taken from a single event.
+const maxEventDays = 366
+
+// dateTimeLayouts are the formats of DTSTART and DTEND, only their dates are
+// used.
+var dateTimeLayouts = []string{"20060102", "20060102T150405Z", "20060102T150405"}
+
+type event struct {
+	start, end string
+	summary    string
+}
+
+// Parse returns the days covered by the events of the calendar, named by the
+// summaries of the events. An all-day event ends before its DTEND, the other
+// events cover every day from their start to their end. Recurrence rules are
+// not expanded.
+func Parse(r io.Reader) ([]models.CalendarDate, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var dates []models.CalendarDate
+	var current *event
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			current = &event{}
+		case current == nil:
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			days, err := current.days()
+			if err != nil {
+				return nil, err
+			}
+			dates = append(dates, days...)
+			current = nil
+		case name == "DTSTART":
+			current.start = value
+		case name == "DTEND":
+			current.end = value
+		case name == "SUMMARY":
+			current.summary = unescape(value)
+		}
+	}
+	if len(dates) == 0 {
+		return nil, fmt.Errorf("no events found")
+	}
+	return dates, nil
+}
+
+// unfold joins the lines continued on the following lines starting with a
+// space or a tab.
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+func (e event) days() ([]models.CalendarDate, error) {
+	if e.start == "" {
+		return nil, fmt.Errorf("event '%s' has no start", e.summary)
+	}
+	start, allDay, err := parseDate(e.start)
+	if err != nil {
+		return nil, fmt.Errorf("event '%s': %w", e.summary, err)
+	}
+
+	end := start
+	if e.end != "" {
+		if end, _, err = parseDate(e.end); err != nil {
+			return nil, fmt.Errorf("event '%s': %w", e.summary, err)
+		}
+		if allDay && end.After(start) {
+			end = end.AddDate(0, 0, -1)
+		}
+	}
+
+	var days []models.CalendarDate
+	for day := start; !day.After(end) && len(days) < maxEventDays; day = day.AddDate(0, 0, 1) {
+		days = append(days, models.CalendarDate{Date: day, Name: e.summary})
+	}
+	return days, nil
+}
+
+// parseDate returns the date of the value at midnight UTC and whether the
+// value is a date without a time.
+func parseDate(value string) (time.Time, bool, error) {
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+			return date, len(value) == len("20060102"), nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid date '%s'", value)
+}
+
+func unescape(s string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(s)
+}
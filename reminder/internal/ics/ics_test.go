@@ -0,0 +1,59 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	date := func(year int, month time.Month, day int, name string) models.CalendarDate {
+		return models.CalendarDate{Date: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), Name: name}
+	}
+
+	t.Run("events", func(t *testing.T) {
+		calendar := strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"BEGIN:VEVENT",
+			"DTSTART;VALUE=DATE:20270101",
+			"DTEND;VALUE=DATE:20270103",
+			"SUMMARY:New Year\\, holidays",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"DTSTART;VALUE=DATE:20270223",
+			"SUMMARY:Defender of the Father",
+			" land Day",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"DTSTART:20270308T000000Z",
+			"DTEND:20270308T235959Z",
+			"SUMMARY:Women's Day",
+			"END:VEVENT",
+			"END:VCALENDAR",
+		}, "\r\n")
+
+		dates, err := Parse(strings.NewReader(calendar))
+		require.NoError(t, err)
+		assert.Equal(t, []models.CalendarDate{
+			date(2027, 1, 1, "New Year, holidays"),
+			date(2027, 1, 2, "New Year, holidays"),
+			date(2027, 2, 23, "Defender of the Fatherland Day"),
+			date(2027, 3, 8, "Women's Day"),
+		}, dates)
+	})
+
+	t.Run("no events", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("BEGIN:VCALENDAR\nEND:VCALENDAR\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		_, err := Parse(strings.NewReader("BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT\n"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,370 @@
+package natural
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxListedTimes bounds the amount of times of day listed one by one, rules
+// firing more often are described by their minutes and hours.
+const maxListedTimes = 6
+
+var (
+	cronMacros = map[string]string{
+		"@yearly":   "0 0 0 1 1 * *",
+		"@annually": "0 0 0 1 1 * *",
+		"@monthly":  "0 0 0 1 * * *",
+		"@weekly":   "0 0 0 * * 0 *",
+		"@daily":    "0 0 0 * * * *",
+		"@hourly":   "0 0 * * * * *",
+	}
+
+	cronMonths = []string{
+		"", "JAN", "FEB", "MAR", "APR", "MAY", "JUN",
+		"JUL", "AUG", "SEP", "OCT", "NOV", "DEC",
+	}
+	monthNames = []string{
+		"", "January", "February", "March", "April", "May", "June",
+		"July", "August", "September", "October", "November", "December",
+	}
+	ordinalNames = []string{"", "first", "second", "third", "fourth", "fifth"}
+
+	stepPattern           = regexp.MustCompile(`^(?:\*|0)/(\d+)$`)
+	rangePattern          = regexp.MustCompile(`^(\w+)-(\w+)$`)
+	nthWeekdayPattern     = regexp.MustCompile(`^(\w+)#([1-5])$`)
+	lastWeekdayPattern    = regexp.MustCompile(`^(\w+)L$`)
+	nearestWeekdayPattern = regexp.MustCompile(`^(\d{1,2})W$`)
+)
+
+// Describe explains the cron rule in English, e.g. "0 12 * * FRI" reads as
+// "At 12:00, only on Friday".
+func Describe(rule string) (string, error) {
+	fields, err := cronFields(rule)
+	if err != nil {
+		return "", err
+	}
+
+	parts, err := describeTime(fields[0], fields[1], fields[2])
+	if err != nil {
+		return "", err
+	}
+	days, err := describeDays(fields[3], fields[5])
+	if err != nil {
+		return "", err
+	}
+	if days != "" {
+		parts = append(parts, days)
+	}
+	if !isAny(fields[4]) {
+		months, err := describeMonths(fields[4])
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, months)
+	}
+	if !isAny(fields[6]) {
+		years, err := values(fields[6], 1970, 2099, nil)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, "only in "+joinInts(years))
+	}
+
+	description := strings.Join(parts, ", ")
+	return strings.ToUpper(description[:1]) + description[1:], nil
+}
+
+// cronFields splits the rule into seven fields from seconds to years the way
+// cronexpr reads it: five fields lack seconds and years, six lack seconds.
+func cronFields(rule string) ([]string, error) {
+	rule = strings.TrimSpace(rule)
+	if macro, ok := cronMacros[strings.ToLower(rule)]; ok {
+		rule = macro
+	}
+
+	fields := strings.Fields(strings.ToUpper(rule))
+	switch len(fields) {
+	case 5:
+		fields = append(append([]string{"0"}, fields...), "*")
+	case 6:
+		fields = append([]string{"0"}, fields...)
+	case 7:
+	default:
+		return nil, fmt.Errorf("a cron rule has 5 to 7 fields, got %d", len(fields))
+	}
+	return fields, nil
+}
+
+func describeTime(second, minute, hour string) ([]string, error) {
+	seconds, err := values(second, 0, 59, nil)
+	if err != nil {
+		return nil, err
+	}
+	minutes, err := values(minute, 0, 59, nil)
+	if err != nil {
+		return nil, err
+	}
+	hours, err := values(hour, 0, 23, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(seconds)*len(minutes)*len(hours) <= maxListedTimes {
+		withSeconds := slices.ContainsFunc(seconds, func(s int) bool { return s != 0 })
+		var times []string
+		for _, h := range hours {
+			for _, m := range minutes {
+				for _, s := range seconds {
+					t := fmt.Sprintf("%02d:%02d", h, m)
+					if withSeconds {
+						t += fmt.Sprintf(":%02d", s)
+					}
+					times = append(times, t)
+				}
+			}
+		}
+		return []string{"at " + joinWords(times)}, nil
+	}
+
+	var parts []string
+	if second != "0" {
+		parts = append(parts, describeUnit(second, seconds, "second"))
+	}
+	if minute != "*" || len(parts) == 0 {
+		parts = append(parts, describeUnit(minute, minutes, "minute"))
+	}
+	switch {
+	case hour == "*":
+	case stepPattern.MatchString(hour):
+		parts = append(parts, describeUnit(hour, hours, "hour"))
+	case isContinuous(hours):
+		parts = append(parts, fmt.Sprintf(
+			"between %02d:00 and %02d:59", hours[0], hours[len(hours)-1],
+		))
+	default:
+		parts = append(parts, "past hours "+joinInts(hours))
+	}
+	return parts, nil
+}
+
+// describeUnit describes a field of a time of day, e.g. "every 15 minutes" or
+// "at minutes 0 and 30".
+func describeUnit(field string, vals []int, unit string) string {
+	if field == "*" {
+		return "every " + unit
+	}
+	if m := stepPattern.FindStringSubmatch(field); m != nil {
+		return fmt.Sprintf("every %s %ss", m[1], unit)
+	}
+	if len(vals) == 1 {
+		return fmt.Sprintf("at %s %d", unit, vals[0])
+	}
+	return fmt.Sprintf("at %ss %s", unit, joinInts(vals))
+}
+
+// describeDays describes the days of month and the days of week. cronexpr
+// fires on the days matching either of them when both are restricted.
+func describeDays(dayOfMonth, dayOfWeek string) (string, error) {
+	var parts []string
+	if !isAny(dayOfMonth) {
+		days, err := describeDaysOfMonth(dayOfMonth)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, days)
+	}
+	if !isAny(dayOfWeek) {
+		days, err := describeDaysOfWeek(dayOfWeek)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, days)
+	}
+	return strings.Join(parts, " or "), nil
+}
+
+func describeDaysOfMonth(field string) (string, error) {
+	switch {
+	case field == "L":
+		return "on the last day of the month", nil
+	case field == "LW":
+		return "on the last weekday of the month", nil
+	case nearestWeekdayPattern.MatchString(field):
+		day := nearestWeekdayPattern.FindStringSubmatch(field)[1]
+		return fmt.Sprintf("on the weekday nearest day %s of the month", day), nil
+	case stepPattern.MatchString(field):
+		return fmt.Sprintf("every %s days", stepPattern.FindStringSubmatch(field)[1]), nil
+	}
+
+	days, err := values(field, 1, 31, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(days) == 1 {
+		return fmt.Sprintf("on day %d of the month", days[0]), nil
+	}
+	return fmt.Sprintf("on days %s of the month", joinInts(days)), nil
+}
+
+func describeDaysOfWeek(field string) (string, error) {
+	if m := nthWeekdayPattern.FindStringSubmatch(field); m != nil {
+		day, err := weekday(m[1])
+		if err != nil {
+			return "", err
+		}
+		n, _ := strconv.Atoi(m[2])
+		return fmt.Sprintf("on the %s %s of the month", ordinalNames[n], day), nil
+	}
+	if m := lastWeekdayPattern.FindStringSubmatch(field); m != nil {
+		day, err := weekday(m[1])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("on the last %s of the month", day), nil
+	}
+
+	days, err := values(field, 0, 7, cronWeekdays)
+	if err != nil {
+		return "", err
+	}
+	for i, day := range days {
+		days[i] = day % 7
+	}
+	slices.Sort(days)
+	days = slices.Compact(days)
+
+	if len(days) > 2 && isContinuous(days) {
+		return fmt.Sprintf(
+			"%s through %s",
+			weekdayName(days[0]),
+			weekdayName(days[len(days)-1]),
+		), nil
+	}
+	names := make([]string, len(days))
+	for i, day := range days {
+		names[i] = weekdayName(day)
+	}
+	return "only on " + joinWords(names), nil
+}
+
+func describeMonths(field string) (string, error) {
+	if m := stepPattern.FindStringSubmatch(field); m != nil {
+		return fmt.Sprintf("every %s months", m[1]), nil
+	}
+
+	months, err := values(field, 1, 12, cronMonths)
+	if err != nil {
+		return "", err
+	}
+	if len(months) > 2 && isContinuous(months) {
+		return fmt.Sprintf(
+			"%s through %s",
+			monthNames[months[0]],
+			monthNames[months[len(months)-1]],
+		), nil
+	}
+	names := make([]string, len(months))
+	for i, month := range months {
+		names[i] = monthNames[month]
+	}
+	return "only in " + joinWords(names), nil
+}
+
+// values expands the list of values, ranges and steps of a cron field into
+// the sorted values it matches. names, if any, are the names of the values
+// starting from min.
+func values(field string, min, max int, names []string) ([]int, error) {
+	var vals []int
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if base, s, ok := strings.Cut(item, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in '%s'", item)
+			}
+			item, step = base, n
+		}
+
+		from, to := min, max
+		switch {
+		case isAny(item):
+		case rangePattern.MatchString(item):
+			m := rangePattern.FindStringSubmatch(item)
+			var err error
+			if from, err = value(m[1], min, max, names); err != nil {
+				return nil, err
+			}
+			if to, err = value(m[2], min, max, names); err != nil {
+				return nil, err
+			}
+		default:
+			v, err := value(item, min, max, names)
+			if err != nil {
+				return nil, err
+			}
+			from = v
+			if step == 1 {
+				to = v
+			}
+		}
+		if from > to {
+			return nil, fmt.Errorf("invalid range '%s'", item)
+		}
+		for v := from; v <= to; v += step {
+			vals = append(vals, v)
+		}
+	}
+	slices.Sort(vals)
+	return slices.Compact(vals), nil
+}
+
+func value(s string, min, max int, names []string) (int, error) {
+	if i := slices.Index(names, s); i >= 0 && s != "" {
+		return i, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("invalid value '%s'", s)
+	}
+	return v, nil
+}
+
+func weekday(s string) (string, error) {
+	day, err := value(s, 0, 7, cronWeekdays)
+	if err != nil {
+		return "", err
+	}
+	return weekdayName(day % 7), nil
+}
+
+func weekdayName(day int) string {
+	return time.Weekday(day).String()
+}
+
+func isAny(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func isContinuous(vals []int) bool {
+	return len(vals) > 0 && vals[len(vals)-1]-vals[0] == len(vals)-1
+}
+
+func joinInts(vals []int) string {
+	words := make([]string, len(vals))
+	for i, v := range vals {
+		words[i] = strconv.Itoa(v)
+	}
+	return joinWords(words)
+}
+
+// joinWords joins the words into "a, b and c".
+func joinWords(words []string) string {
+	if len(words) <= 1 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
@@ -0,0 +1,48 @@
+package natural
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	descriptions := map[string]string{
+		"0 0 12 * * FRI *":     "At 12:00, only on Friday",
+		"0 12 * * FRI":         "At 12:00, only on Friday",
+		"30 9 * * MON-FRI":     "At 09:30, Monday through Friday",
+		"0 9-18/3 * * MON,THU": "At 09:00, 12:00, 15:00 and 18:00, only on Monday and Thursday",
+		"0 12 * * TUE#2":       "At 12:00, on the second Tuesday of the month",
+		"0 17 * * FRIL":        "At 17:00, on the last Friday of the month",
+		"0 10 L * *":           "At 10:00, on the last day of the month",
+		"0 9 1,15 * *":         "At 09:00, on days 1 and 15 of the month",
+		"0 9 1 1-3 *":          "At 09:00, on day 1 of the month, January through March",
+		"*/15 * * * *":         "Every 15 minutes",
+		"*/15 9-17 * * *":      "Every 15 minutes, between 09:00 and 17:59",
+		"0 */2 * * *":          "At minute 0, every 2 hours",
+		"* * * * *":            "Every minute",
+		"*/10 * * * * * *":     "Every 10 seconds",
+		"0 0 0 * * SAT,SUN *":  "At 00:00, only on Sunday and Saturday",
+		"0 8 * * * 2027":       "At 08:00, only in 2027",
+		"@daily":               "At 00:00",
+		"0 12 15W * *":         "At 12:00, on the weekday nearest day 15 of the month",
+		"0 12 1 * MON":         "At 12:00, on day 1 of the month or only on Monday",
+		"0 12 * JUL,AUG *":     "At 12:00, only in July and August",
+		"5,35 * * * *":         "At minutes 5 and 35",
+	}
+	for rule, description := range descriptions {
+		t.Run(rule, func(t *testing.T) {
+			got, err := Describe(rule)
+			require.NoError(t, err)
+			assert.Equal(t, description, got)
+		})
+	}
+
+	for _, rule := range []string{"", "0 12 *", "0 12 * * XYZ", "61 * * * *", "0 12-10 * * *"} {
+		t.Run(rule, func(t *testing.T) {
+			_, err := Describe(rule)
+			assert.Error(t, err)
+		})
+	}
+}
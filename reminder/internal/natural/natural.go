@@ -0,0 +1,453 @@
+// Package natural turns schedule phrases in English or Russian, like "every
+// weekday at 9:30" or "завтра в 10", into cron rules, intervals or one-shot
+// dates, and describes cron rules back in English.
+package natural
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+// Schedule is the interpretation of a phrase. Type is models.ScheduleCron,
+// models.ScheduleInterval or models.ScheduleOnce, the Rule is in the format of
+// the type. Note explains
+// the meaning of a phrase that may be read in another way, if any.
+type Schedule struct {
+	Type string
+	Rule string
+	Note string
+}
+
+const lastOrdinal = -1
+
+var (
+	skipped = set(
+		"every", "each", "on", "the", "of", "and", "at", "month", "months",
+		"каждый", "каждую", "каждое", "каждые", "каждого", "каждой",
+		"по", "и", "в", "во", "месяц", "месяца", "числа", "число",
+	)
+	months   = set("month", "months", "месяц", "месяца")
+	daily    = set("day", "daily", "everyday", "день", "ежедневно")
+	dayWords = set("day", "days", "день", "дни", "дням")
+
+	workdays = set(
+		"weekday", "weekdays", "workday", "workdays",
+		"будни", "будням", "будний", "будние", "рабочий", "рабочие", "рабочим",
+	)
+	weekends = set(
+		"weekend", "weekends",
+		"выходные", "выходным", "выходной",
+	)
+
+	minuteUnits = set("minute", "minutes", "min", "mins", "минуту", "минуты", "минут")
+	hourUnits   = set("hour", "hours", "час", "часа", "часов")
+	hourly      = set("hourly", "ежечасно")
+
+	oneShotDays = map[string]int{
+		"today": 0, "tomorrow": 1,
+		"сегодня": 0, "завтра": 1, "послезавтра": 2,
+	}
+
+	weekdayNames = map[string]time.Weekday{}
+
+	ordinals = map[string]int{
+		"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5, "last": lastOrdinal,
+	}
+
+	meridiems = map[string]bool{
+		"am": false, "pm": true,
+		"утра": false, "ночи": false, "дня": true, "вечера": true,
+	}
+
+	timePattern    = regexp.MustCompile(`^(\d{1,2})(?:[:.](\d{2}))?(am|pm)?$`)
+	ordinalPattern = regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th|-го|-е|-ое|-ого)$`)
+)
+
+func init() {
+	names := map[time.Weekday][]string{
+		time.Sunday:    {"sunday", "sun", "воскресенье", "воскресенья", "воскресеньям", "вс"},
+		time.Monday:    {"monday", "mon", "понедельник", "понедельника", "понедельникам", "пн"},
+		time.Tuesday:   {"tuesday", "tue", "tues", "вторник", "вторника", "вторникам", "вт"},
+		time.Wednesday: {"wednesday", "wed", "среда", "среду", "среды", "средам", "ср"},
+		time.Thursday:  {"thursday", "thu", "thur", "thurs", "четверг", "четверга", "четвергам", "чт"},
+		time.Friday:    {"friday", "fri", "пятница", "пятницу", "пятницы", "пятницам", "пт"},
+		time.Saturday:  {"saturday", "sat", "суббота", "субботу", "субботы", "субботам", "сб"},
+	}
+	for day, forms := range names {
+		weekdayNames[forms[0]+"s"] = day
+		for _, form := range forms {
+			weekdayNames[form] = day
+		}
+	}
+
+	russianOrdinals := map[int][]string{
+		1:           {"первый", "первую", "первое", "первого"},
+		2:           {"второй", "вторую", "второе", "второго"},
+		3:           {"третий", "третью", "третье", "третьего"},
+		4:           {"четвертый", "четвертую", "четвертое", "четвертого"},
+		5:           {"пятый", "пятую", "пятое", "пятого"},
+		lastOrdinal: {"последний", "последнюю", "последнее", "последнего"},
+	}
+	for n, forms := range russianOrdinals {
+		for _, form := range forms {
+			ordinals[form] = n
+		}
+	}
+}
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, word := range words {
+		m[word] = true
+	}
+	return m
+}
+
+// phrase collects what the words of a phrase say about the schedule.
+type phrase struct {
+	hour, minute int
+	hasTime      bool
+
+	daily     bool
+	weekdays  []time.Weekday
+	nth       int
+	monthDays []string
+	ofMonth   bool
+	// nthDay is the number of a day given as "2nd day", it is a day of the
+	// month only if the phrase mentions the month.
+	nthDay int
+
+	interval     int
+	intervalUnit string
+
+	oneShotDay int
+	oneShot    bool
+}
+
+// Parse interprets the phrase. One-shot dates are relative to now and are in
+// its location.
+func Parse(s string, now time.Time) (Schedule, error) {
+	tokens := tokenize(s)
+	if len(tokens) == 0 {
+		return Schedule{}, fmt.Errorf("empty schedule")
+	}
+
+	var p phrase
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+
+		switch {
+		case token == "day" && next == "after" && i+2 < len(tokens) && tokens[i+2] == "tomorrow":
+			p.oneShot, p.oneShotDay = true, 2
+			i += 2
+		case months[token]:
+			p.ofMonth = true
+		case skipped[token]:
+		case workdays[token], weekends[token]:
+			days := []time.Weekday{time.Saturday, time.Sunday}
+			if workdays[token] {
+				days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+			}
+			p.weekdays = append(p.weekdays, days...)
+			if dayWords[next] {
+				i++
+			}
+		case daily[token]:
+			p.daily = true
+		case hourly[token]:
+			p.interval, p.intervalUnit = 1, "hour"
+		case minuteUnits[token]:
+			p.interval, p.intervalUnit = 1, "minute"
+		case hourUnits[token]:
+			p.interval, p.intervalUnit = 1, "hour"
+		case token == "noon" || token == "полдень":
+			p.setTime(12, 0)
+		case token == "midnight" || token == "полночь":
+			p.setTime(0, 0)
+		default:
+			if day, ok := oneShotDays[token]; ok {
+				p.oneShot, p.oneShotDay = true, day
+				continue
+			}
+			if day, ok := weekdayNames[token]; ok {
+				p.weekdays = append(p.weekdays, day)
+				continue
+			}
+			n, ok := parseOrdinal(token)
+			if ok {
+				consumed, err := p.addOrdinal(n, next)
+				if err != nil {
+					return Schedule{}, err
+				}
+				i += consumed
+				continue
+			}
+			consumed, err := p.addNumber(token, next)
+			if err != nil {
+				return Schedule{}, err
+			}
+			i += consumed
+		}
+	}
+	return p.schedule(now)
+}
+
+// tokenize splits the phrase into lowercase words dropping the punctuation
+// around them.
+func tokenize(s string) []string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ',' || r == ';' || r == '\t' || r == '\n'
+	})
+}
+
+func parseOrdinal(token string) (int, bool) {
+	if n, ok := ordinals[token]; ok {
+		return n, true
+	}
+	if m := ordinalPattern.FindStringSubmatch(token); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n, true
+	}
+	return 0, false
+}
+
+// addOrdinal records the ordinal followed by next, either the nth weekday of
+// a month or a day of a month. It returns the number of tokens it consumed
+// after the ordinal.
+func (p *phrase) addOrdinal(n int, next string) (int, error) {
+	if day, ok := weekdayNames[next]; ok {
+		if n > 5 {
+			return 0, fmt.Errorf("there is no %d%s %s in a month", n, ordinalSuffix(n), day)
+		}
+		p.weekdays = append(p.weekdays, day)
+		p.nth = n
+		return 1, nil
+	}
+
+	consumed := 0
+	if dayWords[next] {
+		consumed = 1
+		p.nthDay = n
+	}
+	if n == lastOrdinal {
+		p.monthDays = append(p.monthDays, "L")
+		return consumed, nil
+	}
+	if n < 1 || n > 31 {
+		return 0, fmt.Errorf("invalid day of month %d", n)
+	}
+	p.monthDays = append(p.monthDays, strconv.Itoa(n))
+	return consumed, nil
+}
+
+// addNumber records a number as an interval, a day of a month or a time of
+// day depending on the word following it. It returns the number of tokens it
+// consumed after the number.
+func (p *phrase) addNumber(token string, next string) (int, error) {
+	if n, err := strconv.Atoi(token); err == nil {
+		switch {
+		case minuteUnits[next]:
+			p.interval, p.intervalUnit = n, "minute"
+			return 1, nil
+		case hourUnits[next]:
+			p.interval, p.intervalUnit = n, "hour"
+			return 1, nil
+		case next == "числа" || next == "число":
+			return p.addOrdinal(n, "")
+		}
+	}
+
+	m := timePattern.FindStringSubmatch(token)
+	if m == nil {
+		return 0, fmt.Errorf("cannot understand '%s'", token)
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+
+	consumed := 0
+	meridiem := m[3]
+	pm, ok := meridiems[next]
+	if meridiem == "" && ok {
+		meridiem = next
+		consumed = 1
+	} else {
+		pm = meridiem == "pm"
+	}
+	if meridiem != "" {
+		if hour < 1 || hour > 12 {
+			return 0, fmt.Errorf("invalid time '%s %s'", token, meridiem)
+		}
+		hour %= 12
+		if pm {
+			hour += 12
+		}
+	}
+
+	if hour > 23 || minute > 59 {
+		return 0, fmt.Errorf("invalid time '%s'", token)
+	}
+	p.setTime(hour, minute)
+	return consumed, nil
+}
+
+func (p *phrase) setTime(hour, minute int) {
+	p.hour, p.minute, p.hasTime = hour, minute, true
+}
+
+func (p *phrase) schedule(now time.Time) (Schedule, error) {
+	switch {
+	case p.oneShot:
+		if !p.hasTime {
+			return Schedule{}, fmt.Errorf("specify the time, e.g. 'tomorrow at 10'")
+		}
+		if p.daily || len(p.weekdays) > 0 || len(p.monthDays) > 0 || p.interval > 0 {
+			return Schedule{}, fmt.Errorf("a single day cannot be combined with a repetition")
+		}
+		day := now.AddDate(0, 0, p.oneShotDay)
+		at := time.Date(day.Year(), day.Month(), day.Day(), p.hour, p.minute, 0, 0, now.Location())
+		return Schedule{Type: models.ScheduleOnce, Rule: at.Format(models.OnceLayout)}, nil
+
+	case p.interval > 0:
+		return p.intervalSchedule()
+	}
+
+	if !p.hasTime {
+		return Schedule{}, fmt.Errorf("specify the time, e.g. 'every day at 9:30'")
+	}
+	if p.nth != 0 && len(slices.Compact(slices.Sorted(slices.Values(p.weekdays)))) > 1 {
+		return Schedule{}, fmt.Errorf("only a single day of week can be numbered")
+	}
+	if p.nthDay > 0 && !p.ofMonth {
+		return p.dayIntervalSchedule(now)
+	}
+	if len(p.weekdays) > 0 && len(p.monthDays) > 0 {
+		return Schedule{}, fmt.Errorf("days of week cannot be combined with days of month")
+	}
+	if !p.daily && len(p.weekdays) == 0 && len(p.monthDays) == 0 {
+		return Schedule{}, fmt.Errorf("specify the days, e.g. 'every day' or 'every monday'")
+	}
+
+	monthDays := "*"
+	if len(p.monthDays) > 0 {
+		monthDays = strings.Join(p.monthDays, ",")
+	}
+	rule := fmt.Sprintf("%d %d %s * %s", p.minute, p.hour, monthDays, p.weekdayField())
+	return Schedule{Type: models.ScheduleCron, Rule: rule, Note: p.note()}, nil
+}
+
+// note explains a numbered day of week given without a month, like "every
+// 2nd tuesday", which may be read as every other week as well.
+func (p *phrase) note() string {
+	if p.nth < 2 || p.ofMonth {
+		return ""
+	}
+	day := p.weekdays[0]
+	return fmt.Sprintf(
+		"the %d%s %s means the %s %s of every month, "+
+			"use `RRULE:FREQ=WEEKLY;INTERVAL=%d;BYDAY=%s` for every %d weeks instead",
+		p.nth, ordinalSuffix(p.nth), day,
+		ordinalNames[p.nth], day,
+		p.nth, cronWeekdays[day][:2], p.nth,
+	)
+}
+
+func (p *phrase) intervalSchedule() (Schedule, error) {
+	if p.hasTime || len(p.monthDays) > 0 || p.nth != 0 {
+		return Schedule{}, fmt.Errorf("an interval can only be combined with days of week")
+	}
+
+	var rule string
+	switch p.intervalUnit {
+	case "minute":
+		if p.interval >= 60 || 60%p.interval != 0 {
+			return Schedule{}, fmt.Errorf("every %d minutes cannot be scheduled, use a divisor of 60", p.interval)
+		}
+		rule = fmt.Sprintf("%s * * * %s", step(p.interval), p.weekdayField())
+	default:
+		if p.interval >= 24 || 24%p.interval != 0 {
+			return Schedule{}, fmt.Errorf("every %d hours cannot be scheduled, use a divisor of 24", p.interval)
+		}
+		rule = fmt.Sprintf("0 %s * * %s", step(p.interval), p.weekdayField())
+	}
+	return Schedule{Type: models.ScheduleCron, Rule: rule}, nil
+}
+
+// dayIntervalSchedule reads a phrase like "every 2nd day at 10" as every 2 days
+// starting from the day of now, "every 2nd day of the month" is the 2nd day of
+// every month instead.
+func (p *phrase) dayIntervalSchedule(now time.Time) (Schedule, error) {
+	if p.daily || len(p.weekdays) > 0 || len(p.monthDays) > 1 {
+		return Schedule{}, fmt.Errorf(
+			"every %d%s day cannot be combined with other days, "+
+				"say 'of the month' for a day of every month", p.nthDay, ordinalSuffix(p.nthDay),
+		)
+	}
+	interval := schedule.Interval{
+		Every: p.nthDay,
+		Unit:  'd',
+		Start: time.Date(now.Year(), now.Month(), now.Day(), p.hour, p.minute, 0, 0, time.UTC),
+	}
+	return Schedule{Type: models.ScheduleInterval, Rule: interval.String()}, nil
+}
+
+func step(n int) string {
+	if n == 1 {
+		return "*"
+	}
+	return fmt.Sprintf("*/%d", n)
+}
+
+var cronWeekdays = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+
+func (p *phrase) weekdayField() string {
+	if len(p.weekdays) == 0 {
+		return "*"
+	}
+
+	days := slices.Clone(p.weekdays)
+	slices.Sort(days)
+	days = slices.Compact(days)
+	if p.nth != 0 {
+		if p.nth == lastOrdinal {
+			return cronWeekdays[days[0]] + "L"
+		}
+		return fmt.Sprintf("%s#%d", cronWeekdays[days[0]], p.nth)
+	}
+	if slices.Equal(days, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}) {
+		return "MON-FRI"
+	}
+
+	names := make([]string, len(days))
+	for i, day := range days {
+		names[i] = cronWeekdays[day]
+	}
+	return strings.Join(names, ",")
+}
+
+func ordinalSuffix(n int) string {
+	switch n {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	default:
+		return "th"
+	}
+}
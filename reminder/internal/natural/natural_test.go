@@ -0,0 +1,133 @@
+package natural
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/gorhill/cronexpr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)
+
+	cron := map[string]string{
+		"every weekday at 9:30":          "30 9 * * MON-FRI",
+		"every day at 8am":               "0 8 * * *",
+		"daily at 7:15 pm":               "15 19 * * *",
+		"every monday and friday at 10":  "0 10 * * MON,FRI",
+		"on weekends at midnight":        "0 0 * * SUN,SAT",
+		"last friday of the month at 17": "0 17 * * FRIL",
+		"on the 1st of every month at 9": "0 9 1 * *",
+		"every 15 minutes":               "*/15 * * * *",
+		"every hour on weekdays":         "0 * * * MON-FRI",
+		"каждый будний день в 9:30":      "30 9 * * MON-FRI",
+		"по будням в 9.30":               "30 9 * * MON-FRI",
+		"по понедельникам и пятницам в 7 вечера": "0 19 * * MON,FRI",
+		"каждую среду в 10":                      "0 10 * * WED",
+		"последнюю пятницу месяца в 17:00":       "0 17 * * FRIL",
+		"15 числа каждого месяца в 10":           "0 10 15 * *",
+		"каждые 2 часа":                          "0 */2 * * *",
+		"ежедневно в 8 утра":                     "0 8 * * *",
+	}
+	for phrase, rule := range cron {
+		t.Run(phrase, func(t *testing.T) {
+			schedule, err := Parse(phrase, now)
+			require.NoError(t, err)
+			assert.Equal(t, Schedule{Type: models.ScheduleCron, Rule: rule}, schedule)
+			_, err = cronexpr.Parse(schedule.Rule)
+			assert.NoError(t, err)
+		})
+	}
+
+	once := map[string]string{
+		"tomorrow at 10":                "2026-10-19 10:00:00",
+		"today at 17:30":                "2026-10-18 17:30:00",
+		"the day after tomorrow at 9am": "2026-10-20 09:00:00",
+		"завтра в 10":                   "2026-10-19 10:00:00",
+		"послезавтра в полдень":         "2026-10-20 12:00:00",
+	}
+	for phrase, rule := range once {
+		t.Run(phrase, func(t *testing.T) {
+			schedule, err := Parse(phrase, now)
+			require.NoError(t, err)
+			assert.Equal(t, Schedule{Type: models.ScheduleOnce, Rule: rule}, schedule)
+		})
+	}
+
+	// A numbered day is every that many days unless the phrase mentions the
+	// month.
+	interval := map[string]string{
+		"every 2nd day at 10":     "every 2d from 2026-10-18 10:00",
+		"каждый второй день в 10": "every 2d from 2026-10-18 10:00",
+	}
+	for phrase, rule := range interval {
+		t.Run(phrase, func(t *testing.T) {
+			schedule, err := Parse(phrase, now)
+			require.NoError(t, err)
+			assert.Equal(t, Schedule{Type: models.ScheduleInterval, Rule: rule}, schedule)
+		})
+	}
+	for _, phrase := range []string{"on the 2nd day of every month at 10", "второй день каждого месяца в 10"} {
+		t.Run(phrase, func(t *testing.T) {
+			schedule, err := Parse(phrase, now)
+			require.NoError(t, err)
+			assert.Equal(t, Schedule{Type: models.ScheduleCron, Rule: "0 10 2 * *"}, schedule)
+		})
+	}
+
+	invalid := []string{
+		"",
+		"0 0 12 * * FRI *",
+		"every weekday",
+		"at 10",
+		"every 7 minutes",
+		"every monday at 25:00",
+		"every 6th tuesday at 10",
+		"every monday on the 1st at 10",
+		"tomorrow every day at 10",
+		"every 2nd and 3rd day at 10",
+		"every 2nd day on mondays at 10",
+		"когда-нибудь",
+	}
+	for _, phrase := range invalid {
+		t.Run(phrase, func(t *testing.T) {
+			_, err := Parse(phrase, now)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseNumberedWeekday(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 0, 0, 0, time.UTC)
+	note := "the 2nd Tuesday means the second Tuesday of every month, " +
+		"use `RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TU` for every 2 weeks instead"
+
+	// A numbered day of week is the day of every month rather than every
+	// other week, the phrases not saying so are explained.
+	phrases := map[string]string{
+		"every 2nd tuesday at noon":                 note,
+		"каждый второй вторник в полдень":           note,
+		"every second tuesday of the month at noon": "",
+		"второй вторник каждого месяца в полдень":   "",
+	}
+	for phrase, note := range phrases {
+		t.Run(phrase, func(t *testing.T) {
+			schedule, err := Parse(phrase, now)
+			require.NoError(t, err)
+			assert.Equal(t, Schedule{Type: models.ScheduleCron, Rule: "0 12 * * TUE#2", Note: note}, schedule)
+
+			expr, err := cronexpr.Parse(schedule.Rule)
+			require.NoError(t, err)
+			assert.Equal(t,
+				[]time.Time{
+					time.Date(2026, 11, 10, 12, 0, 0, 0, time.UTC),
+					time.Date(2026, 12, 8, 12, 0, 0, 0, time.UTC),
+				},
+				expr.NextN(now, 2),
+			)
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package rman
+
+import (
+	"slices"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/syncmap"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/rs/zerolog/log"
+)
+
+// calendarTTL is how long the dates of a calendar are served from the cache
+// before they are read from the store again.
+const calendarTTL = 5 * time.Minute
+
+// maxShiftDays bounds how far an occurrence is shifted looking for a working
+// day.
+const maxShiftDays = 366
+
+type cachedCalendar struct {
+	dates     map[string]bool
+	weekend   []time.Weekday
+	expiresAt time.Time
+}
+
+// isWorking tells if the runs can be shifted to the day, the one that is
+// neither excluded nor a weekend day.
+func (c cachedCalendar) isWorking(day time.Time) bool {
+	return !c.dates[day.Format(time.DateOnly)] && !slices.Contains(c.weekend, day.Weekday())
+}
+
+// calendarCache resolves the excluded dates and the weekends of calendars
+// without hitting the store on every scheduling decision.
+type calendarCache struct {
+	store   store
+	entries *syncmap.Map[int64, cachedCalendar]
+}
+
+func newCalendarCache(store store) *calendarCache {
+	return &calendarCache{
+		store:   store,
+		entries: syncmap.New[int64, cachedCalendar](),
+	}
+}
+
+// get returns the calendar with its excluded dates in the time.DateOnly
+// format.
+func (c *calendarCache) get(calendarID int64) cachedCalendar {
+	now := time.Now()
+	if entry, ok := c.entries.Get(calendarID); ok && now.Before(entry.expiresAt) {
+		return entry
+	}
+
+	dates := make(map[string]bool)
+	calendarDates, err := c.store.calendarDates(calendarID)
+	if err != nil {
+		log.Err(err).Int64("Calendar", calendarID).Msg("Cannot get calendar dates")
+	}
+	for _, date := range calendarDates {
+		dates[date.Date.Format(time.DateOnly)] = true
+	}
+	weekend, err := c.store.calendarWeekend(calendarID)
+	if err != nil {
+		log.Err(err).Int64("Calendar", calendarID).Msg("Cannot get calendar weekend")
+		weekend = models.DefaultWeekend
+	}
+	entry := cachedCalendar{
+		dates:     dates,
+		weekend:   models.WeekendDays(weekend),
+		expiresAt: now.Add(calendarTTL),
+	}
+	c.entries.Set(calendarID, entry)
+	return entry
+}
+
+// forget drops the cached calendar, e.g. after its dates changed.
+func (c *calendarCache) forget(calendarID int64) {
+	c.entries.Delete(calendarID)
+}
+
+// calendarSchedule leaves out the runs of the base schedule falling on the
+// dates excluded by a calendar or, with the shift policy, moves them to the
+// same time of the next working day, skipping the weekend of the calendar.
+type calendarSchedule struct {
+	base       schedule.Schedule
+	calendars  *calendarCache
+	calendarID int64
+	shift      bool
+}
+
+func withCalendar(
+	base schedule.Schedule,
+	calendars *calendarCache,
+	reminder models.Reminder,
+) schedule.Schedule {
+	if !reminder.CalendarID.Valid {
+		return base
+	}
+	return calendarSchedule{
+		base:       base,
+		calendars:  calendars,
+		calendarID: reminder.CalendarID.Int64,
+		shift:      reminder.HolidayPolicy == models.HolidayShift,
+	}
+}
+
+func (s calendarSchedule) Next(from time.Time) time.Time {
+	calendar := s.calendars.get(s.calendarID)
+	if len(calendar.dates) == 0 {
+		return s.base.Next(from)
+	}
+	isExcluded := func(t time.Time) bool {
+		return calendar.dates[t.Format(time.DateOnly)]
+	}
+
+	if !s.shift {
+		next := s.base.Next(from)
+		for !next.IsZero() && isExcluded(next) {
+			next = s.base.Next(endOfDay(next))
+		}
+		return next
+	}
+
+	// The runs on the non-working days right before from may be shifted
+	// past it, so they are looked through as well.
+	start := startOfDay(from)
+	for i := 0; i < maxShiftDays; i++ {
+		day := start.AddDate(0, 0, -1)
+		if calendar.isWorking(day) {
+			break
+		}
+		start = day
+	}
+
+	var best time.Time
+	for run := s.base.Next(start.Add(-time.Nanosecond)); !run.IsZero(); run = s.base.Next(run) {
+		if !best.IsZero() && run.After(best) {
+			break
+		}
+		if !isExcluded(run) {
+			if run.After(from) {
+				best = run
+			}
+			continue
+		}
+
+		day := startOfDay(run)
+		for i := 0; i < maxShiftDays && !calendar.isWorking(day); i++ {
+			day = day.AddDate(0, 0, 1)
+		}
+		if day.Before(startOfDay(from)) {
+			run = endOfDay(run)
+			continue
+		}
+		shifted := time.Date(
+			day.Year(), day.Month(), day.Day(),
+			run.Hour(), run.Minute(), run.Second(), 0,
+			run.Location(),
+		)
+		if shifted.After(from) && (best.IsZero() || shifted.Before(best)) {
+			best = shifted
+		}
+	}
+	return best
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// endOfDay returns the last instant of the day of t, the runs following it are
+// on the next days.
+func endOfDay(t time.Time) time.Time {
+	return startOfDay(t).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
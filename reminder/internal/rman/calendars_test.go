@@ -0,0 +1,99 @@
+package rman
+
+import (
+	"testing"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/gorhill/cronexpr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// calendarStore is a memory store holding the dates of a single calendar.
+type calendarStore struct {
+	memoryStore
+	dates []models.CalendarDate
+}
+
+func (s *calendarStore) calendarDates(int64) ([]models.CalendarDate, error) {
+	return s.dates, nil
+}
+
+// weekendStore is a calendar store with the weekend of the calendar set.
+type weekendStore struct {
+	calendarStore
+	weekend string
+}
+
+func (s *weekendStore) calendarWeekend(int64) (string, error) {
+	return s.weekend, nil
+}
+
+func TestCalendarSchedule(t *testing.T) {
+	expr, err := cronexpr.Parse("0 9 * * MON-FRI")
+	require.NoError(t, err)
+
+	at := func(month time.Month, day int, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, time.UTC)
+	}
+	date := func(month time.Month, day int) models.CalendarDate {
+		return models.CalendarDate{Date: at(month, day, 0)}
+	}
+	// Thursday, December 31 and Friday, January 1 are days off.
+	store := &calendarStore{dates: []models.CalendarDate{date(12, 31), {Date: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)}}}
+	calendars := newCalendarCache(store)
+	monday := time.Date(2027, 1, 4, 9, 0, 0, 0, time.UTC)
+
+	t.Run("skip", func(t *testing.T) {
+		s := calendarSchedule{base: expr, calendars: calendars, calendarID: 1}
+		assert.Equal(t, at(12, 30, 9), s.Next(at(12, 29, 12)))
+		assert.Equal(t, monday, s.Next(at(12, 30, 12)))
+	})
+
+	t.Run("shift", func(t *testing.T) {
+		s := calendarSchedule{base: expr, calendars: calendars, calendarID: 1, shift: true}
+		assert.Equal(t, monday, s.Next(at(12, 30, 12)))
+		assert.Equal(t, monday, s.Next(monday.Add(-time.Hour)))
+
+		// Both days off are shifted to Monday at the same time, so the
+		// runs coincide and Monday is fired once.
+		assert.Equal(t, time.Date(2027, 1, 5, 9, 0, 0, 0, time.UTC), s.Next(monday))
+	})
+
+	t.Run("shifted runs are found after the day off", func(t *testing.T) {
+		newYearsEve, err := cronexpr.Parse("0 18 31 12 *")
+		require.NoError(t, err)
+		s := calendarSchedule{base: newYearsEve, calendars: calendars, calendarID: 1, shift: true}
+		shifted := time.Date(2027, 1, 4, 18, 0, 0, 0, time.UTC)
+		assert.Equal(t, shifted, s.Next(at(12, 30, 12)))
+		assert.Equal(t, shifted, s.Next(time.Date(2027, 1, 2, 12, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("shift past the weekend of the calendar", func(t *testing.T) {
+		newYearsEve, err := cronexpr.Parse("0 18 31 12 *")
+		require.NoError(t, err)
+		store := &weekendStore{
+			calendarStore: calendarStore{dates: store.dates},
+			weekend:       "fri,sat",
+		}
+		s := calendarSchedule{base: newYearsEve, calendars: newCalendarCache(store), calendarID: 1, shift: true}
+
+		// Friday is a day off and Saturday is a weekend day, Sunday is the
+		// next working day.
+		assert.Equal(t, time.Date(2027, 1, 3, 18, 0, 0, 0, time.UTC), s.Next(at(12, 30, 12)))
+	})
+
+	t.Run("runs on weekend days out of the calendar are sent", func(t *testing.T) {
+		daily, err := cronexpr.Parse("0 9 * * *")
+		require.NoError(t, err)
+		store := &weekendStore{
+			calendarStore: calendarStore{dates: store.dates},
+			weekend:       "fri,sat",
+		}
+		s := calendarSchedule{base: daily, calendars: newCalendarCache(store), calendarID: 1, shift: true}
+		saturday := time.Date(2027, 1, 2, 9, 0, 0, 0, time.UTC)
+		assert.Equal(t, saturday, s.Next(at(12, 30, 12)))
+		assert.Equal(t, saturday.AddDate(0, 0, 1), s.Next(saturday))
+	})
+}
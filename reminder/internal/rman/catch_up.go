@@ -0,0 +1,123 @@
+package rman
+
+import (
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+// maxMissedRuns bounds the amount of reminds fired for a single reminder by
+// the `all` catch-up policy, so a frequent rule does not flood the channel
+// after a long downtime.
+const maxMissedRuns = 100
+
+// missedRuns returns the occurrences of the reminder that were due between
+// its last persisted run and now, filtered by the reminder's catch-up policy.
+// The occurrences that fell into a pause of the reminder are not missed, nor
+// are the ones past the limit of its occurrences.
+func missedRuns(
+	reminder models.Reminder,
+	expr schedule.Schedule,
+	loc *time.Location,
+	now time.Time,
+) []time.Time {
+	if reminder.CatchUp == models.CatchUpSkip || !reminder.NextRunAt.Valid {
+		return nil
+	}
+
+	due := reminder.NextRunAt.Time
+	if reminder.LastRunAt.Valid && !due.After(reminder.LastRunAt.Time) {
+		due = expr.Next(reminder.LastRunAt.Time.In(loc))
+	}
+
+	var runs []time.Time
+	for !due.IsZero() && !due.After(now) {
+		if !reminder.PausedAt(due) {
+			runs = append(runs, due.UTC())
+		}
+		if len(runs) > maxMissedRuns {
+			runs = runs[1:]
+		}
+		due = expr.Next(due.In(loc))
+	}
+	if reminder.MaxOccurrences.Valid {
+		left := max(reminder.MaxOccurrences.Int64-reminder.Occurrences, 0)
+		if int64(len(runs)) > left {
+			runs = runs[:left]
+		}
+	}
+
+	if reminder.CatchUp == models.CatchUpAll || len(runs) == 0 {
+		return runs
+	}
+	return runs[len(runs)-1:]
+}
+
+// undeliveredRuns returns the runs of the reminder fired before a restart of
+// the in-memory remind manager whose reminds were neither completed nor moved
+// to the dead letters, so they were lost with it. These are the runs after the
+// last handled one, or after the reminder was last edited, and before its
+// persisted next run. The runs in a pause of the reminder were not fired.
+func undeliveredRuns(
+	reminder models.Reminder,
+	expr schedule.Schedule,
+	loc *time.Location,
+) []time.Time {
+	if !reminder.NextRunAt.Valid {
+		return nil
+	}
+	from := reminder.ModifiedAt
+	if reminder.LastRunAt.Valid && reminder.LastRunAt.Time.After(from) {
+		from = reminder.LastRunAt.Time
+	}
+	if from.IsZero() {
+		return nil
+	}
+
+	var runs []time.Time
+	due := expr.Next(from.In(loc))
+	for !due.IsZero() && due.Before(reminder.NextRunAt.Time) {
+		if !reminder.PausedAt(due) {
+			runs = append(runs, due.UTC())
+		}
+		if len(runs) > maxPendingPerReminder {
+			runs = runs[1:]
+		}
+		due = expr.Next(due.In(loc))
+	}
+	return runs
+}
+
+// dueRuns splits the occurrences of the reminder due by now into the one
+// fired on time, if it is at most grace old, and the missed ones before it
+// filtered by the catch-up policy. The missed runs are not fired by the
+// latest policy when there is a run on time.
+func dueRuns(
+	reminder models.Reminder,
+	expr schedule.Schedule,
+	loc *time.Location,
+	now time.Time,
+	grace time.Duration,
+) (missed []time.Time, onTime time.Time) {
+	policy := reminder.CatchUp
+	reminder.CatchUp = models.CatchUpAll
+	runs := missedRuns(reminder, expr, loc, now)
+	if len(runs) == 0 {
+		return nil, time.Time{}
+	}
+
+	if last := runs[len(runs)-1]; now.Sub(last) <= grace {
+		onTime = last
+		runs = runs[:len(runs)-1]
+	}
+
+	switch {
+	case policy == models.CatchUpAll:
+		return runs, onTime
+	case policy == models.CatchUpLatest && onTime.IsZero() && len(runs) > 0:
+		return runs[len(runs)-1:], onTime
+	default:
+		return nil, onTime
+	}
+}
@@ -0,0 +1,178 @@
+package rman
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/gorhill/cronexpr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissedRuns(t *testing.T) {
+	expr, err := cronexpr.Parse("0 0 12 * * * *")
+	require.NoError(t, err)
+
+	now := time.Date(2026, 11, 5, 15, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2026, 11, d, 12, 0, 0, 0, time.UTC)
+	}
+	reminder := func(policy string, lastRun, nextRun time.Time) models.Reminder {
+		return models.Reminder{
+			ID:        1,
+			Rule:      "0 0 12 * * * *",
+			CatchUp:   policy,
+			LastRunAt: sql.NullTime{Time: lastRun, Valid: !lastRun.IsZero()},
+			NextRunAt: sql.NullTime{Time: nextRun, Valid: !nextRun.IsZero()},
+		}
+	}
+
+	t.Run("all", func(t *testing.T) {
+		runs := missedRuns(reminder(models.CatchUpAll, day(1), day(2)), expr, time.UTC, now)
+		assert.Equal(t, []time.Time{day(2), day(3), day(4), day(5)}, runs)
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		runs := missedRuns(reminder(models.CatchUpLatest, day(1), day(2)), expr, time.UTC, now)
+		assert.Equal(t, []time.Time{day(5)}, runs)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		runs := missedRuns(reminder(models.CatchUpSkip, day(1), day(2)), expr, time.UTC, now)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("next run already fired", func(t *testing.T) {
+		runs := missedRuns(reminder(models.CatchUpAll, day(4), day(4)), expr, time.UTC, now)
+		assert.Equal(t, []time.Time{day(5)}, runs)
+	})
+
+	t.Run("next run in future", func(t *testing.T) {
+		runs := missedRuns(reminder(models.CatchUpAll, day(5), day(6)), expr, time.UTC, now)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("max occurrences", func(t *testing.T) {
+		r := reminder(models.CatchUpAll, day(1), day(2))
+		r.MaxOccurrences = sql.NullInt64{Int64: 4, Valid: true}
+		r.Occurrences = 2
+		runs := missedRuns(r, expr, time.UTC, now)
+		assert.Equal(t, []time.Time{day(2), day(3)}, runs)
+	})
+
+	t.Run("never scheduled", func(t *testing.T) {
+		runs := missedRuns(reminder(models.CatchUpAll, time.Time{}, time.Time{}), expr, time.UTC, now)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("paused runs are not missed", func(t *testing.T) {
+		paused := reminder(models.CatchUpAll, day(1), day(2))
+		paused.Paused = true
+		paused.PausedUntil = sql.NullTime{Time: day(4), Valid: true}
+		runs := missedRuns(paused, expr, time.UTC, now)
+		assert.Equal(t, []time.Time{day(4), day(5)}, runs)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		everySecond, err := cronexpr.Parse("* * * * * * *")
+		require.NoError(t, err)
+
+		start := now.Add(-time.Hour)
+		runs := missedRuns(reminder(models.CatchUpAll, time.Time{}, start), everySecond, time.UTC, now)
+		assert.Len(t, runs, maxMissedRuns)
+		assert.Equal(t, now, runs[len(runs)-1])
+	})
+}
+
+func TestDueRuns(t *testing.T) {
+	expr, err := cronexpr.Parse("0 0 12 * * * *")
+	require.NoError(t, err)
+
+	day := func(d int) time.Time {
+		return time.Date(2026, 11, d, 12, 0, 0, 0, time.UTC)
+	}
+	reminder := func(policy string, nextRun time.Time) models.Reminder {
+		return models.Reminder{
+			ID:        1,
+			Rule:      "0 0 12 * * * *",
+			CatchUp:   policy,
+			NextRunAt: sql.NullTime{Time: nextRun, Valid: true},
+		}
+	}
+	onTimeNow := day(5).Add(time.Second)
+	lateNow := day(5).Add(3 * time.Hour)
+
+	t.Run("on time", func(t *testing.T) {
+		missed, onTime := dueRuns(reminder(models.CatchUpSkip, day(5)), expr, time.UTC, onTimeNow, time.Minute)
+		assert.Empty(t, missed)
+		assert.Equal(t, day(5), onTime)
+	})
+
+	t.Run("not due", func(t *testing.T) {
+		missed, onTime := dueRuns(reminder(models.CatchUpAll, day(6)), expr, time.UTC, onTimeNow, time.Minute)
+		assert.Empty(t, missed)
+		assert.Zero(t, onTime)
+	})
+
+	for policy, expected := range map[string]struct {
+		onTime []time.Time
+		late   []time.Time
+	}{
+		models.CatchUpAll:    {[]time.Time{day(3), day(4)}, []time.Time{day(3), day(4), day(5)}},
+		models.CatchUpLatest: {nil, []time.Time{day(5)}},
+		models.CatchUpSkip:   {nil, nil},
+	} {
+		t.Run(policy, func(t *testing.T) {
+			missed, onTime := dueRuns(reminder(policy, day(3)), expr, time.UTC, onTimeNow, time.Minute)
+			assert.Equal(t, expected.onTime, missed)
+			assert.Equal(t, day(5), onTime)
+
+			missed, onTime = dueRuns(reminder(policy, day(3)), expr, time.UTC, lateNow, time.Minute)
+			assert.Equal(t, expected.late, missed)
+			assert.Zero(t, onTime)
+		})
+	}
+}
+
+func TestUndeliveredRuns(t *testing.T) {
+	expr, err := cronexpr.Parse("0 0 12 * * * *")
+	require.NoError(t, err)
+
+	day := func(d int) time.Time {
+		return time.Date(2026, 11, d, 12, 0, 0, 0, time.UTC)
+	}
+	reminder := func(lastRun, nextRun time.Time) models.Reminder {
+		return models.Reminder{
+			ID:         1,
+			Rule:       "0 0 12 * * * *",
+			CatchUp:    models.CatchUpSkip,
+			LastRunAt:  sql.NullTime{Time: lastRun, Valid: !lastRun.IsZero()},
+			NextRunAt:  sql.NullTime{Time: nextRun, Valid: !nextRun.IsZero()},
+			ModifiedAt: day(1).Add(-time.Hour),
+		}
+	}
+
+	t.Run("runs fired after the last handled one", func(t *testing.T) {
+		runs := undeliveredRuns(reminder(day(2), day(5)), expr, time.UTC)
+		assert.Equal(t, []time.Time{day(3), day(4)}, runs)
+	})
+
+	t.Run("never handled", func(t *testing.T) {
+		runs := undeliveredRuns(reminder(time.Time{}, day(3)), expr, time.UTC)
+		assert.Equal(t, []time.Time{day(1), day(2)}, runs)
+	})
+
+	t.Run("every run handled", func(t *testing.T) {
+		runs := undeliveredRuns(reminder(day(4), day(5)), expr, time.UTC)
+		assert.Empty(t, runs)
+	})
+
+	t.Run("runs before an edit", func(t *testing.T) {
+		r := reminder(day(2), day(5))
+		r.ModifiedAt = day(3).Add(time.Hour)
+		runs := undeliveredRuns(r, expr, time.UTC)
+		assert.Equal(t, []time.Time{day(4)}, runs)
+	})
+}
@@ -0,0 +1,664 @@
+package rman
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/syncmap"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+// dbPollInterval is how often the database is checked for due reminders and
+// for reminds available for delivery.
+const dbPollInterval = time.Second
+
+// dbGrace is how late a run may be fired before it is considered missed and
+// left to the catch-up policy of the reminder.
+const dbGrace = time.Minute
+
+// dbBatchSize bounds the amount of reminders fired in a single transaction.
+const dbBatchSize = 100
+
+// dbRemindManager keeps the schedule and the reminds in the database, so
+// several instances of the reminder service can run at once and nothing is
+// lost on restart. Every instance fires the due reminders it manages to lock,
+// the ones locked by other instances are skipped.
+type dbRemindManager struct {
+	db        *sql.DB
+	schedules *syncmap.Map[string, schedule.Schedule]
+	locations *locationCache
+	calendars *calendarCache
+	updates   *notifier
+	wake      chan struct{}
+
+	mu sync.Mutex
+	// modified holds the modification times of the reminders seen by the
+	// last reconciliation.
+	modified map[int64]time.Time
+}
+
+func NewDatabase(
+	db *sql.DB,
+	defaultLocation *time.Location,
+) RemindManager {
+	rm := &dbRemindManager{
+		db:        db,
+		schedules: syncmap.New[string, schedule.Schedule](),
+		locations: newLocationCache(dbStore{db: db}, defaultLocation),
+		calendars: newCalendarCache(dbStore{db: db}),
+		updates:   newNotifier(),
+		wake:      make(chan struct{}, 1),
+		modified:  make(map[int64]time.Time),
+	}
+	go rm.dispatch()
+	return rm
+}
+
+func (rm *dbRemindManager) TriggerReminds(reminds ...models.Remind) {
+	for _, remind := range reminds {
+		if remind.OccurrenceID == "" {
+			remind.OccurrenceID = newOccurrenceID()
+		}
+		if remind.ScheduledAt.IsZero() {
+			remind.ScheduledAt = time.Now().UTC()
+		}
+		if err := repositories.CreateRemind(rm.db, remind); err != nil {
+			log.Err(err).Any("Remind", remind).Msg("Cannot store remind")
+		}
+	}
+	rm.updates.broadcast()
+}
+
+func (rm *dbRemindManager) GetReminds() []models.Remind {
+	reminds, err := repositories.GetPendingReminds(rm.db)
+	if err != nil {
+		log.Err(err).Msg("Cannot get reminds")
+	}
+	return reminds
+}
+
+func (rm *dbRemindManager) ClaimReminds(
+	claimant string,
+	lease time.Duration,
+) []models.Remind {
+	reminds, err := repositories.ClaimReminds(rm.db, claimant, time.Now(), lease)
+	if err != nil {
+		log.Err(err).Str("Claimant", claimant).Msg("Cannot claim reminds")
+	}
+	return reminds
+}
+
+func (rm *dbRemindManager) CompleteReminds(occurrenceIDs ...string) {
+	if err := repositories.DeleteReminds(rm.db, occurrenceIDs...); err != nil {
+		log.Err(err).Strs("Reminds", occurrenceIDs).Msg("Cannot complete reminds")
+	}
+}
+
+func (rm *dbRemindManager) FailRemind(occurrenceID string, reason string) bool {
+	failed, err := repositories.FailRemind(rm.db, occurrenceID, reason, time.Now())
+	if err != nil {
+		log.Err(err).Str("Remind", occurrenceID).Msg("Cannot fail remind")
+	}
+	return failed
+}
+
+func (rm *dbRemindManager) GetDeadReminds() []models.DeadRemind {
+	reminds, err := repositories.GetDeadReminds(rm.db)
+	if err != nil {
+		log.Err(err).Msg("Cannot get dead reminds")
+	}
+	return reminds
+}
+
+func (rm *dbRemindManager) ReplayReminds(occurrenceIDs ...string) {
+	if err := repositories.ReplayReminds(rm.db, occurrenceIDs...); err != nil {
+		log.Err(err).Strs("Reminds", occurrenceIDs).Msg("Cannot replay reminds")
+	}
+	rm.updates.broadcast()
+}
+
+func (rm *dbRemindManager) DiscardDeadReminds(occurrenceIDs ...string) {
+	if err := repositories.DeleteDeadReminds(rm.db, occurrenceIDs...); err != nil {
+		log.Err(err).Strs("Reminds", occurrenceIDs).Msg("Cannot discard dead reminds")
+	}
+}
+
+func (rm *dbRemindManager) Subscribe() (<-chan struct{}, func()) {
+	return rm.updates.subscribe()
+}
+
+// AddReminders schedules the reminders that have no next run yet. The runs of
+// the already scheduled ones, including the runs missed while no instance was
+// running, are fired by the dispatcher, unless their future next run is
+// outdated by an edit made directly in the database, then they are
+// rescheduled. Reminders paused indefinitely are left without a next run.
+// The reminders are the baseline for the edits looked for by Reconcile.
+func (rm *dbRemindManager) AddReminders(reminders ...models.Reminder) {
+	now := time.Now()
+	for _, reminder := range reminders {
+		if reminder.ArchivedAt.Valid {
+			continue
+		}
+		rm.mu.Lock()
+		rm.modified[reminder.ID] = reminder.ModifiedAt
+		rm.mu.Unlock()
+
+		expr, err := parseSchedule(rm.schedules, rm.calendars, reminder)
+		if err != nil {
+			log.Err(err).
+				Str("rule", reminder.Rule).
+				Msg("Cannot parse schedule")
+			continue
+		}
+
+		if reminder.NextRunAt.Valid {
+			if nextRunOutdated(reminder, expr, rm.locations.forReminder(reminder), now) {
+				if _, err := rm.reschedule(reminder, now); err != nil {
+					log.Err(err).Int64("Reminder", reminder.ID).Msg("Cannot reschedule reminder")
+				}
+			}
+			continue
+		}
+
+		nextRun, suspended := nextRunAfter(reminder, expr, rm.locations.forReminder(reminder), now)
+		if suspended {
+			continue
+		}
+		if nextRun.IsZero() {
+			if err := rm.finish(rm.db, reminder, now); err != nil {
+				log.Err(err).Int64("Reminder", reminder.ID).Msg("Cannot finish reminder")
+			}
+			continue
+		}
+
+		if _, err := repositories.SetReminderNextRunIfUnset(rm.db, reminder.ID, nextRun); err != nil {
+			log.Err(err).Int64("Reminder", reminder.ID).Msg("Cannot persist next run time")
+		}
+	}
+
+	select {
+	case rm.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RescheduleReminders recomputes the next runs of the edited reminders and
+// returns them. The runs of the new rules that passed since the last run are
+// not caught up.
+func (rm *dbRemindManager) RescheduleReminders(
+	reminders ...models.Reminder,
+) map[int64]time.Time {
+	now := time.Now()
+	nextRuns := make(map[int64]time.Time, len(reminders))
+	for _, reminder := range reminders {
+		nextRun, err := rm.reschedule(reminder, now)
+		if err != nil {
+			log.Err(err).Int64("Reminder", reminder.ID).Msg("Cannot reschedule reminder")
+			continue
+		}
+		if !nextRun.IsZero() {
+			nextRuns[reminder.ID] = nextRun
+		}
+
+		rm.mu.Lock()
+		rm.modified[reminder.ID] = reminder.ModifiedAt
+		rm.mu.Unlock()
+	}
+
+	select {
+	case rm.wake <- struct{}{}:
+	default:
+	}
+	return nextRuns
+}
+
+// reschedule persists the run of the reminder following now. Reminders
+// without future runs are finished, the ones paused indefinitely are
+// unscheduled and the archived ones are left as is.
+func (rm *dbRemindManager) reschedule(reminder models.Reminder, now time.Time) (time.Time, error) {
+	if reminder.ArchivedAt.Valid {
+		return time.Time{}, nil
+	}
+	expr, err := parseSchedule(rm.schedules, rm.calendars, reminder)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	nextRun, suspended := nextRunAfter(reminder, expr, rm.locations.forReminder(reminder), now)
+	if suspended {
+		return nextRun, repositories.UnscheduleReminder(rm.db, reminder.ID)
+	}
+	if nextRun.IsZero() {
+		return nextRun, rm.finish(rm.db, reminder, now)
+	}
+	return nextRun, repositories.UpdateReminderNextRun(rm.db, reminder.ID, nextRun)
+}
+
+func (rm *dbRemindManager) UpdateReminderOwner(id int64, owner string) {
+	if err := repositories.UpdateRemindsOwner(rm.db, id, owner); err != nil {
+		log.Err(err).Int64("Reminder", id).Msg("Cannot update reminds owner")
+	}
+}
+
+// UpdateRemindWebhook does nothing, the webhook of the owner is read along
+// with the reminds.
+func (rm *dbRemindManager) UpdateRemindWebhook(int64, string) {}
+
+func (rm *dbRemindManager) RemoveReminders(ids ...int64) {
+	if err := repositories.DeleteRemindsOfReminders(rm.db, ids...); err != nil {
+		log.Err(err).Ints64("Reminders", ids).Msg("Cannot remove reminds")
+	}
+	if err := repositories.DeleteSnoozesOfReminders(rm.db, ids...); err != nil {
+		log.Err(err).Ints64("Reminders", ids).Msg("Cannot remove snoozes")
+	}
+}
+
+// SnoozeReminder stores an extra occurrence of the reminder at runAt, it is
+// fired by the dispatcher of any instance. The runs of the reminder's rule are
+// not affected.
+func (rm *dbRemindManager) SnoozeReminder(reminder models.Reminder, runAt time.Time) error {
+	if err := repositories.CreateSnooze(rm.db, reminder.ID, runAt); err != nil {
+		return err
+	}
+
+	select {
+	case rm.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// RescheduleChannel recomputes the next runs of the reminders of the channel
+// after its time zone changed and returns them. Reminders with their own time
+// zone are not affected. Other instances pick up the new time zone once their
+// cached one expires.
+func (rm *dbRemindManager) RescheduleChannel(channel string) map[int64]time.Time {
+	rm.locations.forget(channel)
+
+	reminders, err := repositories.GetRemindersByChannel(rm.db, channel)
+	if err != nil {
+		log.Err(err).Str("Channel", channel).Msg("Cannot get reminders of channel")
+		return nil
+	}
+
+	now := time.Now()
+	nextRuns := make(map[int64]time.Time, len(reminders))
+	for _, reminder := range reminders {
+		if !reminder.NextRunAt.Valid || reminder.TimeZone.Valid {
+			continue
+		}
+		nextRun, err := rm.reschedule(reminder, now)
+		if err != nil {
+			log.Err(err).Int64("Reminder", reminder.ID).Msg("Cannot reschedule reminder")
+			continue
+		}
+		if !nextRun.IsZero() {
+			nextRuns[reminder.ID] = nextRun
+		}
+	}
+
+	select {
+	case rm.wake <- struct{}{}:
+	default:
+	}
+	return nextRuns
+}
+
+// RescheduleCalendar recomputes the next runs of the reminders using the
+// calendar after its dates changed and returns them. Other instances pick up
+// the new dates once their cached ones expire.
+func (rm *dbRemindManager) RescheduleCalendar(calendarID int64) map[int64]time.Time {
+	rm.calendars.forget(calendarID)
+
+	reminders, err := repositories.GetRemindersByCalendar(rm.db, calendarID)
+	if err != nil {
+		log.Err(err).Int64("Calendar", calendarID).Msg("Cannot get reminders of calendar")
+		return nil
+	}
+
+	now := time.Now()
+	nextRuns := make(map[int64]time.Time, len(reminders))
+	for _, reminder := range reminders {
+		if !reminder.NextRunAt.Valid {
+			continue
+		}
+		nextRun, err := rm.reschedule(reminder, now)
+		if err != nil {
+			log.Err(err).Int64("Reminder", reminder.ID).Msg("Cannot reschedule reminder")
+			continue
+		}
+		if !nextRun.IsZero() {
+			nextRuns[reminder.ID] = nextRun
+		}
+	}
+
+	select {
+	case rm.wake <- struct{}{}:
+	default:
+	}
+	return nextRuns
+}
+
+// Reconcile schedules the reminders that have no next run and reschedules
+// the ones modified since they were seen last, by AddReminders or the previous
+// reconciliation. Reminders seen for the first time are rescheduled if their
+// next run is outdated. Reminds of reminders missing from the database are
+// cancelled.
+func (rm *dbRemindManager) Reconcile(reminders ...models.Reminder) ReconcileResult {
+	var result ReconcileResult
+	var unscheduled []models.Reminder
+	stored := make(map[int64]bool, len(reminders))
+	now := time.Now()
+
+	for _, reminder := range reminders {
+		stored[reminder.ID] = true
+		rm.mu.Lock()
+		seen, ok := rm.modified[reminder.ID]
+		rm.modified[reminder.ID] = reminder.ModifiedAt
+		rm.mu.Unlock()
+		if reminder.ArchivedAt.Valid {
+			continue
+		}
+
+		expr, err := parseSchedule(rm.schedules, rm.calendars, reminder)
+		if err != nil {
+			result.Invalid = append(result.Invalid, reminder.ID)
+			continue
+		}
+
+		switch {
+		// Reminders paused indefinitely have no next run until resumed.
+		case !reminder.NextRunAt.Valid && (!reminder.Paused || reminder.PausedUntil.Valid):
+			result.Added = append(result.Added, reminder.ID)
+			unscheduled = append(unscheduled, reminder)
+		case ok && reminder.ModifiedAt.After(seen),
+			!ok && nextRunOutdated(reminder, expr, rm.locations.forReminder(reminder), now):
+			result.Rescheduled = append(result.Rescheduled, reminder.ID)
+			if _, err := rm.reschedule(reminder, now); err != nil {
+				log.Err(err).Int64("Reminder", reminder.ID).Msg("Cannot reschedule reminder")
+			}
+		}
+	}
+	rm.mu.Lock()
+	for id := range rm.modified {
+		if !stored[id] {
+			delete(rm.modified, id)
+		}
+	}
+	rm.mu.Unlock()
+
+	if len(unscheduled) > 0 {
+		rm.AddReminders(unscheduled...)
+	}
+
+	ids, err := repositories.GetRemindReminderIDs(rm.db)
+	if err != nil {
+		log.Err(err).Msg("Cannot get reminders with reminds")
+	}
+	for _, id := range ids {
+		if !stored[id] {
+			result.Cancelled = append(result.Cancelled, id)
+		}
+	}
+	if len(result.Cancelled) > 0 {
+		rm.RemoveReminders(result.Cancelled...)
+	}
+
+	result.log()
+	return result
+}
+
+// dispatch fires the due reminders every poll interval and notifies the
+// subscribers while there are reminds available for delivery, including the
+// ones fired by other instances.
+func (rm *dbRemindManager) dispatch() {
+	ticker := time.NewTicker(dbPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-rm.wake:
+		}
+
+		now := time.Now()
+		if err := rm.fireDue(now); err != nil {
+			log.Err(err).Msg("Cannot fire due reminders")
+		}
+
+		count, err := repositories.CountClaimableReminds(rm.db, now)
+		if err != nil {
+			log.Err(err).Msg("Cannot count reminds")
+		} else if count > 0 {
+			rm.updates.broadcast()
+		}
+	}
+}
+
+// fireDue fires the due reminders and then the due snoozes in batches, each
+// in a transaction holding the locks of the batch.
+func (rm *dbRemindManager) fireDue(now time.Time) error {
+	for {
+		fired, err := rm.fireBatch(now)
+		if err != nil {
+			return err
+		}
+		if fired < dbBatchSize {
+			break
+		}
+	}
+	for {
+		fired, err := rm.fireSnoozeBatch(now)
+		if err != nil {
+			return err
+		}
+		if fired < dbBatchSize {
+			return nil
+		}
+	}
+}
+
+func (rm *dbRemindManager) fireBatch(now time.Time) (int, error) {
+	tx, err := rm.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("fire due reminders: begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reminders, err := repositories.LockDueReminders(tx, now, dbBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("fire due reminders: %w", err)
+	}
+
+	for _, reminder := range reminders {
+		if err := rm.fire(tx, reminder, now); err != nil {
+			return 0, fmt.Errorf("fire reminder %d: %w", reminder.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("fire due reminders: commit: %w", err)
+	}
+	if len(reminders) > 0 {
+		rm.updates.broadcast()
+	}
+	return len(reminders), nil
+}
+
+// fireSnoozeBatch stores the reminds of the due snoozes. They never replace or
+// are replaced by other occurrences and do not count as runs of the rule.
+// Snoozes of deleted reminders are dropped.
+func (rm *dbRemindManager) fireSnoozeBatch(now time.Time) (int, error) {
+	tx, err := rm.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("fire due snoozes: begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	snoozes, err := repositories.LockDueSnoozes(tx, now, dbBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("fire due snoozes: %w", err)
+	}
+
+	for _, snooze := range snoozes {
+		reminder, err := repositories.GetReminder(tx, snooze.ReminderID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return 0, fmt.Errorf("fire snooze %d: get reminder: %w", snooze.ID, err)
+		default:
+			_, err := rm.addRemind(tx, *reminder, snooze.RunAt, models.OverlapQueue, now)
+			if err != nil {
+				return 0, fmt.Errorf("fire snooze %d: %w", snooze.ID, err)
+			}
+		}
+		if err := repositories.DeleteSnooze(tx, snooze.ID); err != nil {
+			return 0, fmt.Errorf("fire snooze %d: %w", snooze.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("fire due snoozes: commit: %w", err)
+	}
+	if len(snoozes) > 0 {
+		rm.updates.broadcast()
+	}
+	return len(snoozes), nil
+}
+
+// fire stores the due reminds of the reminder and schedules its next run.
+// Reminders without future runs are finished.
+func (rm *dbRemindManager) fire(tx *sql.Tx, reminder models.Reminder, now time.Time) error {
+	expr, err := parseSchedule(rm.schedules, rm.calendars, reminder)
+	if err != nil {
+		log.Err(err).
+			Str("rule", reminder.Rule).
+			Msg("Cannot parse schedule, unscheduling reminder")
+		return repositories.UnscheduleReminder(tx, reminder.ID)
+	}
+
+	loc := rm.locations.forReminder(reminder)
+	missed, onTime := dueRuns(reminder, expr, loc, now, dbGrace)
+
+	var lastRun time.Time
+	var runs int
+	for _, runAt := range missed {
+		log.Info().
+			Any("Reminder", reminder).
+			Time("Missed time", runAt).
+			Msg("Catching up missed remind")
+		queued, err := rm.addRemind(tx, reminder, runAt, models.OverlapQueue, now)
+		if err != nil {
+			return err
+		}
+		lastRun = runAt
+		if queued {
+			runs++
+		}
+	}
+	if !onTime.IsZero() {
+		queued, err := rm.addRemind(tx, reminder, onTime, reminder.Overlap, now)
+		if err != nil {
+			return err
+		}
+		lastRun = onTime
+		if queued {
+			runs++
+		}
+	}
+
+	if !lastRun.IsZero() {
+		if err := repositories.UpdateReminderLastRun(tx, reminder.ID, lastRun, runs); err != nil {
+			return err
+		}
+		reminder.Occurrences += int64(runs)
+	}
+
+	nextRun, suspended := nextRunAfter(reminder, expr, loc, now)
+	if suspended {
+		return repositories.UnscheduleReminder(tx, reminder.ID)
+	}
+	if nextRun.IsZero() {
+		return rm.finish(tx, reminder, now)
+	}
+
+	log.Debug().
+		Int64("Reminder", reminder.ID).
+		Time("Next time", nextRun).
+		Msg("Next trigger time calculated")
+	return repositories.UpdateReminderNextRun(tx, reminder.ID, nextRun)
+}
+
+// finish takes the reminder without runs left off the schedule and tells its
+// owner about it if the reminder asks for it.
+func (rm *dbRemindManager) finish(db repositories.Querier, reminder models.Reminder, now time.Time) error {
+	if err := finishReminder(db, reminder); err != nil {
+		return err
+	}
+	if reminder.NotifyFinish {
+		return repositories.CreateRemind(db, finishNotice(reminder, now))
+	}
+	return nil
+}
+
+// addRemind stores an occurrence of the reminder resolving the overlap with
+// its undelivered occurrences according to the policy. It reports whether the
+// occurrence was stored. Coalescing never discards occurrences leased for
+// delivery.
+func (rm *dbRemindManager) addRemind(
+	tx *sql.Tx,
+	reminder models.Reminder,
+	runAt time.Time,
+	overlap string,
+	now time.Time,
+) (bool, error) {
+	ids, leased, err := repositories.GetPendingRemindIDs(tx, reminder.ID, now)
+	if err != nil {
+		return false, err
+	}
+
+	if len(ids) > 0 {
+		switch overlap {
+		case models.OverlapDrop:
+			log.Warn().
+				Any("Reminder", reminder).
+				Time("Run time", runAt).
+				Msg("Previous remind is not completed yet, dropping the new one")
+			return false, nil
+		case models.OverlapQueue:
+			if len(ids) >= maxPendingPerReminder {
+				if err := repositories.DeleteReminds(tx, ids[0]); err != nil {
+					return false, err
+				}
+			}
+		default:
+			var unleased []string
+			for i, id := range ids {
+				if !leased[i] {
+					unleased = append(unleased, id)
+				}
+			}
+			if err := repositories.DeleteReminds(tx, unleased...); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	err = repositories.CreateRemind(tx, models.Remind{
+		OccurrenceID: newOccurrenceID(),
+		ScheduledAt:  runAt.UTC(),
+		ReminderId:   reminder.ID,
+		Owner:        reminder.Owner,
+		Name:         reminder.Name,
+		Rule:         reminder.Rule,
+		Channel:      reminder.Channel,
+		Message:      reminder.Message,
+	})
+	return err == nil, err
+}
@@ -0,0 +1,101 @@
+package rman
+
+import (
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/syncmap"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/rs/zerolog/log"
+)
+
+// locationTTL is how long a channel time zone is served from the cache
+// before it is read from the store again.
+const locationTTL = 5 * time.Minute
+
+type cachedLocation struct {
+	loc       *time.Location
+	expiresAt time.Time
+}
+
+// locationCache resolves channel time zones without hitting the store on
+// every scheduling decision.
+type locationCache struct {
+	store           store
+	defaultLocation *time.Location
+	entries         *syncmap.Map[string, cachedLocation]
+	// zones holds the time zones of reminders, they never change.
+	zones *syncmap.Map[string, *time.Location]
+}
+
+func newLocationCache(store store, defaultLocation *time.Location) *locationCache {
+	return &locationCache{
+		store:           store,
+		defaultLocation: defaultLocation,
+		entries:         syncmap.New[string, cachedLocation](),
+		zones:           syncmap.New[string, *time.Location](),
+	}
+}
+
+func (c *locationCache) get(channel string) *time.Location {
+	now := time.Now()
+	if entry, ok := c.entries.Get(channel); ok && now.Before(entry.expiresAt) {
+		return entry.loc
+	}
+
+	loc := c.load(channel)
+	c.entries.Set(channel, cachedLocation{loc: loc, expiresAt: now.Add(locationTTL)})
+	return loc
+}
+
+// forReminder resolves the time zone of the reminder, its own one takes
+// precedence over the time zone of its channel.
+func (c *locationCache) forReminder(reminder models.Reminder) *time.Location {
+	if !reminder.TimeZone.Valid {
+		return c.get(reminder.Channel)
+	}
+
+	timeZone := reminder.TimeZone.String
+	if loc, ok := c.zones.Get(timeZone); ok {
+		return loc
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Int64("Reminder", reminder.ID).
+			Str("Location", timeZone).
+			Msg("Cannot parse location, using channel TZ")
+		return c.get(reminder.Channel)
+	}
+	c.zones.Set(timeZone, loc)
+	return loc
+}
+
+// forget drops the cached time zone of the channel, e.g. after it changed.
+func (c *locationCache) forget(channel string) {
+	c.entries.Delete(channel)
+}
+
+func (c *locationCache) load(channel string) *time.Location {
+	timeZone, err := c.store.channelTimeZone(channel)
+	if err != nil {
+		log.Info().
+			Err(err).
+			Str("Channel", channel).
+			Interface("Default location", c.defaultLocation).
+			Msg("Channel time zone is not set, using default TZ")
+		return c.defaultLocation
+	}
+
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("Channel", channel).
+			Str("Location", timeZone).
+			Interface("Default location", c.defaultLocation).
+			Msg("Cannot parse location, using default TZ")
+		return c.defaultLocation
+	}
+	return loc
+}
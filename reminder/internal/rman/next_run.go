@@ -0,0 +1,138 @@
+package rman
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/syncmap"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
+)
+
+// nextRunAfter returns the run of the reminder following after, skipping the runs
+// that fall into its pause. A reminder paused indefinitely has no next run and
+// is reported as suspended rather than finished, a reminder that ran as many
+// times as it may has no next run.
+func nextRunAfter(
+	reminder models.Reminder,
+	expr schedule.Schedule,
+	loc *time.Location,
+	after time.Time,
+) (next time.Time, suspended bool) {
+	if !reminder.OccurrencesLeft() {
+		return time.Time{}, false
+	}
+	if reminder.PausedAt(after) {
+		if !reminder.PausedUntil.Valid {
+			return time.Time{}, true
+		}
+		after = reminder.PausedUntil.Time.Add(-time.Nanosecond)
+	}
+	return expr.Next(after.In(loc)).UTC(), false
+}
+
+// nextRunOutdated reports whether the future next run stored for the reminder
+// is not a run of its rule in loc, so the rule or the time zone of the
+// reminder was changed directly in the database. Due runs are left as they are.
+func nextRunOutdated(
+	reminder models.Reminder,
+	expr schedule.Schedule,
+	loc *time.Location,
+	now time.Time,
+) bool {
+	if !reminder.NextRunAt.Valid || !reminder.NextRunAt.Time.After(now) {
+		return false
+	}
+	stored := reminder.NextRunAt.Time
+	nextRun, _ := nextRunAfter(reminder, expr, loc, stored.Add(-time.Nanosecond))
+	return !nextRun.Equal(stored)
+}
+
+// finishReminder takes the reminder without runs left off the schedule. It is
+// archived rather than deleted, so a reminder with a mistyped rule does not
+// vanish without a trace.
+func finishReminder(db repositories.Querier, reminder models.Reminder) error {
+	return repositories.ArchiveReminder(db, reminder.ID, time.Now())
+}
+
+// finishNotice is the remind telling the owner of the finished reminder, or
+// its channel if it has no owner, that the reminder has no runs left.
+func finishNotice(reminder models.Reminder, now time.Time) models.Remind {
+	channel := reminder.Channel
+	if reminder.Owner.Valid {
+		channel = "@" + reminder.Owner.String
+	}
+	return models.Remind{
+		OccurrenceID: newOccurrenceID(),
+		ScheduledAt:  now.UTC(),
+		ReminderId:   reminder.ID,
+		Owner:        reminder.Owner,
+		Name:         reminder.Name,
+		Rule:         reminder.Rule,
+		Channel:      channel,
+		Message: fmt.Sprintf(
+			"Reminder %d \"%s\" has finished, it has no runs left",
+			reminder.ID,
+			reminder.Name,
+		),
+	}
+}
+
+// NextRuns returns up to n runs of the reminder following after in loc,
+// skipping its pauses, its runs out of its bounds and the dates excluded by
+// its calendar read from db. It
+// does not take the snoozes of the reminder into account.
+func NextRuns(
+	db *sql.DB,
+	reminder models.Reminder,
+	loc *time.Location,
+	after time.Time,
+	n int,
+) ([]time.Time, error) {
+	return NewPreview(db).NextRuns(reminder, loc, after, n)
+}
+
+// Preview computes the next runs of several reminders sharing the parsed
+// schedules and the calendars read from the store between them, e.g. to list
+// the reminders of a channel.
+type Preview struct {
+	schedules *syncmap.Map[string, schedule.Schedule]
+	calendars *calendarCache
+}
+
+func NewPreview(db *sql.DB) *Preview {
+	return &Preview{
+		schedules: syncmap.New[string, schedule.Schedule](),
+		calendars: newCalendarCache(dbStore{db: db}),
+	}
+}
+
+// NextRuns returns up to n runs of the reminder, see the NextRuns function.
+func (p *Preview) NextRuns(
+	reminder models.Reminder,
+	loc *time.Location,
+	after time.Time,
+	n int,
+) ([]time.Time, error) {
+	expr, err := parseSchedule(p.schedules, p.calendars, reminder)
+	if err != nil {
+		return nil, err
+	}
+	if reminder.ArchivedAt.Valid {
+		return nil, nil
+	}
+
+	var runs []time.Time
+	for len(runs) < n {
+		next, _ := nextRunAfter(reminder, expr, loc, after)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+		after = next
+		reminder.Occurrences++
+	}
+	return runs, nil
+}
@@ -0,0 +1,109 @@
+package rman
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNextRuns(t *testing.T) {
+	after := time.Date(2026, 11, 5, 15, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2026, 11, d, 12, 0, 0, 0, time.UTC)
+	}
+
+	t.Run("cron", func(t *testing.T) {
+		reminder := models.Reminder{Rule: "0 12 * * *"}
+		runs, err := NextRuns(nil, reminder, time.UTC, after, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{day(6), day(7), day(8)}, runs)
+	})
+
+	t.Run("pauses are skipped", func(t *testing.T) {
+		reminder := models.Reminder{
+			Rule:        "0 12 * * *",
+			Paused:      true,
+			PausedUntil: sql.NullTime{Time: day(8), Valid: true},
+		}
+		runs, err := NextRuns(nil, reminder, time.UTC, after, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{day(8), day(9)}, runs)
+	})
+
+	t.Run("one-shot", func(t *testing.T) {
+		reminder := models.Reminder{Rule: "2026-11-10 12:00:00", ScheduleType: models.ScheduleOnce}
+		runs, err := NextRuns(nil, reminder, time.UTC, after, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{day(10)}, runs)
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		reminder := models.Reminder{Rule: "every 3d from 2026-11-01 12:00", ScheduleType: models.ScheduleInterval}
+		runs, err := NextRuns(nil, reminder, time.UTC, after, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{day(7), day(10), day(13)}, runs)
+	})
+
+	t.Run("recurrence rule", func(t *testing.T) {
+		reminder := models.Reminder{
+			Rule:         "DTSTART:20261101T120000 RRULE:FREQ=DAILY;INTERVAL=2;COUNT=5 EXDATE:20261107T120000",
+			ScheduleType: models.ScheduleRRule,
+		}
+		runs, err := NextRuns(nil, reminder, time.UTC, after, 3)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{day(9)}, runs)
+	})
+
+	t.Run("bounds", func(t *testing.T) {
+		reminder := models.Reminder{
+			Rule:     "0 12 * * *",
+			StartsAt: sql.NullTime{Time: day(8), Valid: true},
+			EndsAt:   sql.NullTime{Time: day(10), Valid: true},
+		}
+		runs, err := NextRuns(nil, reminder, time.UTC, after, 5)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{day(8), day(9), day(10)}, runs)
+	})
+
+	t.Run("max occurrences", func(t *testing.T) {
+		reminder := models.Reminder{
+			Rule:           "0 12 * * *",
+			MaxOccurrences: sql.NullInt64{Int64: 3, Valid: true},
+			Occurrences:    1,
+		}
+		runs, err := NextRuns(nil, reminder, time.UTC, after, 5)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{day(6), day(7)}, runs)
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		_, err := NextRuns(nil, models.Reminder{Rule: "every day"}, time.UTC, after, 3)
+		assert.Error(t, err)
+	})
+}
+
+func TestNextRunOutdated(t *testing.T) {
+	now := time.Date(2026, 11, 5, 15, 0, 0, 0, time.UTC)
+	nextRun := func(t time.Time) sql.NullTime { return sql.NullTime{Time: t, Valid: true} }
+	reminder := models.Reminder{Rule: "0 12 * * *"}
+	expr, err := schedule.Parse(models.ScheduleCron, reminder.Rule)
+	require.NoError(t, err)
+
+	reminder.NextRunAt = nextRun(time.Date(2026, 11, 6, 12, 0, 0, 0, time.UTC))
+	assert.False(t, nextRunOutdated(reminder, expr, time.UTC, now))
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	assert.True(t, nextRunOutdated(reminder, expr, berlin, now))
+
+	reminder.NextRunAt = nextRun(time.Date(2026, 11, 6, 18, 0, 0, 0, time.UTC))
+	assert.True(t, nextRunOutdated(reminder, expr, time.UTC, now))
+
+	reminder.NextRunAt = nextRun(time.Date(2026, 11, 5, 14, 0, 0, 0, time.UTC))
+	assert.False(t, nextRunOutdated(reminder, expr, time.UTC, now), "due runs are left to the dispatcher")
+}
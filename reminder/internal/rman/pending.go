@@ -0,0 +1,295 @@
+package rman
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+// maxPendingPerReminder bounds the amount of undelivered occurrences queued
+// for a single reminder. The oldest ones are discarded first.
+const maxPendingPerReminder = 100
+
+type pendingRemind struct {
+	remind      models.Remind
+	leasedUntil time.Time
+	// run is set for the occurrences of the runs of the reminder's rule.
+	run bool
+}
+
+func (p *pendingRemind) leased(now time.Time) bool {
+	return now.Before(p.leasedUntil)
+}
+
+// pendingReminds holds triggered occurrences until they are completed.
+// Claimed occurrences are leased and are not handed out again until the lease
+// expires, the lease does not restrict who completes them. Occurrences that failed permanently are
+// moved to the dead letters until they are replayed or discarded.
+type pendingReminds struct {
+	mu         sync.Mutex
+	reminds    map[string]*pendingRemind
+	byReminder map[int64][]string
+	dead       map[string]models.DeadRemind
+	deadIDs    map[int64][]string
+	// handled is called with the runs of the reminders' rules that were
+	// completed or moved to the dead letters, outside of the lock.
+	handled func(runs ...models.Remind)
+}
+
+func newPendingReminds(handled func(runs ...models.Remind)) *pendingReminds {
+	return &pendingReminds{
+		reminds:    make(map[string]*pendingRemind),
+		byReminder: make(map[int64][]string),
+		dead:       make(map[string]models.DeadRemind),
+		deadIDs:    make(map[int64][]string),
+		handled:    handled,
+	}
+}
+
+func newOccurrenceID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// add stores the occurrence resolving a conflict with the undelivered
+// occurrences of the same reminder according to the overlap policy. It
+// reports whether the occurrence was stored. Coalescing never discards
+// occurrences leased for delivery. The run flag marks the occurrences of the
+// runs of the reminder's rule.
+func (p *pendingReminds) add(remind models.Remind, overlap string, run bool) bool {
+	if remind.OccurrenceID == "" {
+		remind.OccurrenceID = newOccurrenceID()
+	}
+	if remind.ScheduledAt.IsZero() {
+		remind.ScheduledAt = time.Now().UTC()
+	}
+	if remind.IdempotencyKey == "" {
+		remind.IdempotencyKey = models.IdempotencyKey(remind.ReminderId, remind.ScheduledAt)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ids := p.byReminder[remind.ReminderId]
+	if len(ids) > 0 {
+		switch overlap {
+		case models.OverlapDrop:
+			return false
+		case models.OverlapQueue:
+			if len(ids) >= maxPendingPerReminder {
+				delete(p.reminds, ids[0])
+				ids = ids[1:]
+			}
+		default:
+			now := time.Now()
+			ids = slices.DeleteFunc(ids, func(id string) bool {
+				if p.reminds[id].leased(now) {
+					return false
+				}
+				delete(p.reminds, id)
+				return true
+			})
+		}
+	}
+
+	p.reminds[remind.OccurrenceID] = &pendingRemind{remind: remind, run: run}
+	p.byReminder[remind.ReminderId] = append(ids, remind.OccurrenceID)
+	return true
+}
+
+// list returns pending occurrences ordered by their scheduled time.
+func (p *pendingReminds) list() []models.Remind {
+	p.mu.Lock()
+	reminds := make([]models.Remind, 0, len(p.reminds))
+	for _, pending := range p.reminds {
+		reminds = append(reminds, pending.remind)
+	}
+	p.mu.Unlock()
+
+	sortReminds(reminds)
+	return reminds
+}
+
+// claim leases every occurrence that is not leased at the moment and returns
+// them ordered by their scheduled time.
+func (p *pendingReminds) claim(lease time.Duration) []models.Remind {
+	now := time.Now()
+	var reminds []models.Remind
+
+	p.mu.Lock()
+	for _, pending := range p.reminds {
+		if pending.leased(now) {
+			continue
+		}
+		pending.leasedUntil = now.Add(lease)
+		reminds = append(reminds, pending.remind)
+	}
+	p.mu.Unlock()
+
+	sortReminds(reminds)
+	return reminds
+}
+
+func sortReminds(reminds []models.Remind) {
+	slices.SortFunc(reminds, func(a, b models.Remind) int {
+		if c := a.ScheduledAt.Compare(b.ScheduledAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ReminderId, b.ReminderId)
+	})
+}
+
+// unindex removes the occurrence from the per reminder index.
+func unindex(index map[int64][]string, reminderID int64, occurrenceID string) {
+	ids := slices.DeleteFunc(index[reminderID], func(id string) bool {
+		return id == occurrenceID
+	})
+	if len(ids) == 0 {
+		delete(index, reminderID)
+	} else {
+		index[reminderID] = ids
+	}
+}
+
+func (p *pendingReminds) complete(occurrenceIDs ...string) {
+	var runs []models.Remind
+	p.mu.Lock()
+	for _, occurrenceID := range occurrenceIDs {
+		pending, ok := p.reminds[occurrenceID]
+		if !ok {
+			continue
+		}
+		delete(p.reminds, occurrenceID)
+		unindex(p.byReminder, pending.remind.ReminderId, occurrenceID)
+		if pending.run {
+			runs = append(runs, pending.remind)
+		}
+	}
+	p.mu.Unlock()
+
+	if len(runs) > 0 && p.handled != nil {
+		p.handled(runs...)
+	}
+}
+
+// fail moves the occurrence to the dead letters. Replayed dead letters are no
+// longer runs, they were handled once already.
+func (p *pendingReminds) fail(occurrenceID string, reason string) bool {
+	p.mu.Lock()
+	pending, ok := p.reminds[occurrenceID]
+	if !ok {
+		p.mu.Unlock()
+		return false
+	}
+	reminderID := pending.remind.ReminderId
+	delete(p.reminds, occurrenceID)
+	unindex(p.byReminder, reminderID, occurrenceID)
+
+	ids := p.deadIDs[reminderID]
+	if len(ids) >= maxPendingPerReminder {
+		delete(p.dead, ids[0])
+		ids = ids[1:]
+	}
+	p.dead[occurrenceID] = models.DeadRemind{
+		Remind:   pending.remind,
+		Error:    reason,
+		FailedAt: time.Now().UTC(),
+	}
+	p.deadIDs[reminderID] = append(ids, occurrenceID)
+	p.mu.Unlock()
+
+	if pending.run && p.handled != nil {
+		p.handled(pending.remind)
+	}
+	return true
+}
+
+// listDead returns the dead letters ordered by their scheduled time.
+func (p *pendingReminds) listDead() []models.DeadRemind {
+	p.mu.Lock()
+	dead := make([]models.DeadRemind, 0, len(p.dead))
+	for _, remind := range p.dead {
+		dead = append(dead, remind)
+	}
+	p.mu.Unlock()
+
+	slices.SortFunc(dead, func(a, b models.DeadRemind) int {
+		if c := a.ScheduledAt.Compare(b.ScheduledAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ReminderId, b.ReminderId)
+	})
+	return dead
+}
+
+// replay moves the dead letters back to the occurrences waiting for delivery.
+func (p *pendingReminds) replay(occurrenceIDs ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, occurrenceID := range occurrenceIDs {
+		dead, ok := p.dead[occurrenceID]
+		if !ok {
+			continue
+		}
+		reminderID := dead.ReminderId
+		delete(p.dead, occurrenceID)
+		unindex(p.deadIDs, reminderID, occurrenceID)
+
+		p.reminds[occurrenceID] = &pendingRemind{remind: dead.Remind}
+		p.byReminder[reminderID] = append(p.byReminder[reminderID], occurrenceID)
+	}
+}
+
+func (p *pendingReminds) discard(occurrenceIDs ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, occurrenceID := range occurrenceIDs {
+		if dead, ok := p.dead[occurrenceID]; ok {
+			delete(p.dead, occurrenceID)
+			unindex(p.deadIDs, dead.ReminderId, occurrenceID)
+		}
+	}
+}
+
+func (p *pendingReminds) removeReminders(reminderIDs ...int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, reminderID := range reminderIDs {
+		for _, id := range p.byReminder[reminderID] {
+			delete(p.reminds, id)
+		}
+		delete(p.byReminder, reminderID)
+
+		for _, id := range p.deadIDs[reminderID] {
+			delete(p.dead, id)
+		}
+		delete(p.deadIDs, reminderID)
+	}
+}
+
+// apply modifies both undelivered and dead occurrences of the reminder.
+func (p *pendingReminds) apply(
+	reminderID int64,
+	f func(remind models.Remind) models.Remind,
+) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, id := range p.byReminder[reminderID] {
+		p.reminds[id].remind = f(p.reminds[id].remind)
+	}
+	for _, id := range p.deadIDs[reminderID] {
+		dead := p.dead[id]
+		dead.Remind = f(dead.Remind)
+		p.dead[id] = dead
+	}
+}
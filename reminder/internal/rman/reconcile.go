@@ -0,0 +1,97 @@
+package rman
+
+import (
+	"container/heap"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/rs/zerolog/log"
+)
+
+// ReconcileResult lists the reminders whose schedule was changed to match
+// the database.
+type ReconcileResult struct {
+	Added       []int64 `json:"added"`
+	Rescheduled []int64 `json:"rescheduled"`
+	Cancelled   []int64 `json:"cancelled"`
+	// Invalid reminders have rules that cannot be parsed, they are not
+	// scheduled.
+	Invalid []int64 `json:"invalid"`
+}
+
+func (r ReconcileResult) changed() bool {
+	return len(r.Added) > 0 || len(r.Rescheduled) > 0 || len(r.Cancelled) > 0
+}
+
+func (r ReconcileResult) log() {
+	if len(r.Invalid) > 0 {
+		log.Warn().Ints64("Reminders", r.Invalid).Msg("Reminders with invalid rules are not scheduled")
+	}
+	if r.changed() {
+		log.Info().
+			Ints64("Added", r.Added).
+			Ints64("Rescheduled", r.Rescheduled).
+			Ints64("Cancelled", r.Cancelled).
+			Msg("Schedule reconciled with the database")
+	}
+}
+
+// Reconcile makes the schedule match the reminders stored in the database.
+// Reminders missing from the schedule are added, the ones modified since
+// they were scheduled are rescheduled and the ones missing from the database
+// are cancelled along with their undelivered reminds. Archived reminders are
+// cancelled too, their undelivered reminds are still delivered.
+func (rm *defaultRemindManager) Reconcile(reminders ...models.Reminder) ReconcileResult {
+	var result ReconcileResult
+	var added, edited []models.Reminder
+	stored := make(map[int64]bool, len(reminders))
+
+	rm.mu.Lock()
+	for _, reminder := range reminders {
+		stored[reminder.ID] = true
+		if reminder.ArchivedAt.Valid {
+			if s, ok := rm.scheduled[reminder.ID]; ok {
+				if s.index >= 0 {
+					heap.Remove(&rm.queue, s.index)
+				}
+				delete(rm.scheduled, reminder.ID)
+				result.Cancelled = append(result.Cancelled, reminder.ID)
+			}
+			continue
+		}
+		if _, err := parseSchedule(rm.schedules, rm.calendars, reminder); err != nil {
+			result.Invalid = append(result.Invalid, reminder.ID)
+			continue
+		}
+
+		s, ok := rm.scheduled[reminder.ID]
+		switch {
+		case !ok:
+			result.Added = append(result.Added, reminder.ID)
+			added = append(added, reminder)
+		case reminder.ModifiedAt.After(s.reminder.ModifiedAt):
+			result.Rescheduled = append(result.Rescheduled, reminder.ID)
+			edited = append(edited, reminder)
+		}
+	}
+	var removed []int64
+	for id := range rm.scheduled {
+		if !stored[id] {
+			removed = append(removed, id)
+		}
+	}
+	rm.mu.Unlock()
+
+	if len(removed) > 0 {
+		rm.RemoveReminders(removed...)
+		result.Cancelled = append(result.Cancelled, removed...)
+	}
+	if len(added) > 0 {
+		rm.AddReminders(added...)
+	}
+	if len(edited) > 0 {
+		rm.RescheduleReminders(edited...)
+	}
+
+	result.log()
+	return result
+}
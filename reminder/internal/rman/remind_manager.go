@@ -1,199 +1,546 @@
 package rman
 
 import (
+	"container/heap"
 	"database/sql"
-	"fmt"
+	"math"
+	"sync"
 	"time"
 
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/syncmap"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
-	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
-	"github.com/gorhill/cronexpr"
 	"github.com/rs/zerolog/log"
 )
 
 type RemindManager interface {
 	TriggerReminds(reminders ...models.Remind)
 	GetReminds() []models.Remind
-	CompleteReminds(ids ...int64)
+	ClaimReminds(claimant string, lease time.Duration) []models.Remind
+	CompleteReminds(occurrenceIDs ...string)
+	FailRemind(occurrenceID string, reason string) bool
+	GetDeadReminds() []models.DeadRemind
+	ReplayReminds(occurrenceIDs ...string)
+	DiscardDeadReminds(occurrenceIDs ...string)
+	Subscribe() (updates <-chan struct{}, cancel func())
 	AddReminders(reminders ...models.Reminder)
+	RescheduleReminders(reminders ...models.Reminder) map[int64]time.Time
 	UpdateReminderOwner(id int64, owner string)
 	UpdateRemindWebhook(id int64, webhook string)
 	RemoveReminders(ids ...int64)
+	RescheduleChannel(channel string) map[int64]time.Time
+	RescheduleCalendar(calendarID int64) map[int64]time.Time
+	SnoozeReminder(reminder models.Reminder, runAt time.Time) error
+	Reconcile(reminders ...models.Reminder) ReconcileResult
 }
 
+// defaultRemindManager keeps every scheduled reminder in a single priority
+// queue ordered by the next run time. One dispatcher goroutine sleeps until
+// the earliest run and fires all the reminders that are due.
 type defaultRemindManager struct {
-	cancels         *syncmap.Map[int64, chan<- bool]
-	completes       *syncmap.Map[int64, chan<- bool]
-	reminds         *syncmap.Map[int64, models.Remind]
-	defaultLocation *time.Location
-	db              *sql.DB
+	mu        sync.Mutex
+	queue     scheduleQueue
+	scheduled map[int64]*scheduledReminder
+	snoozed   []snoozedReminder
+	wake      chan struct{}
+	reminds   *pendingReminds
+	updates   *notifier
+	schedules *syncmap.Map[string, schedule.Schedule]
+	locations *locationCache
+	calendars *calendarCache
+	store     store
 }
 
 func New(
 	db *sql.DB,
 	defaultLocation *time.Location,
 ) RemindManager {
-	return &defaultRemindManager{
-		cancels:         syncmap.New[int64, chan<- bool](),
-		completes:       syncmap.New[int64, chan<- bool](),
-		reminds:         syncmap.New[int64, models.Remind](),
-		db:              db,
-		defaultLocation: defaultLocation,
+	rm := newDefaultRemindManager(dbStore{db: db}, defaultLocation)
+	go rm.dispatch()
+	return rm
+}
+
+func newDefaultRemindManager(
+	store store,
+	defaultLocation *time.Location,
+) *defaultRemindManager {
+	rm := &defaultRemindManager{
+		scheduled: make(map[int64]*scheduledReminder),
+		wake:      make(chan struct{}, 1),
+		updates:   newNotifier(),
+		schedules: syncmap.New[string, schedule.Schedule](),
+		locations: newLocationCache(store, defaultLocation),
+		calendars: newCalendarCache(store),
+		store:     store,
 	}
+	rm.reminds = newPendingReminds(rm.persistRuns)
+	return rm
 }
 
+// TriggerReminds makes the reminds available for delivery in addition to the
+// undelivered ones.
 func (rm *defaultRemindManager) TriggerReminds(reminds ...models.Remind) {
 	for _, remind := range reminds {
-		rm.reminds.Set(remind.ReminderId, remind)
+		rm.reminds.add(remind, models.OverlapQueue, false)
 	}
+	rm.updates.broadcast()
 }
 
 func (rm *defaultRemindManager) GetReminds() []models.Remind {
-	var reminders []models.Remind
-	rm.reminds.Range(func(key int64, value models.Remind) bool {
-		reminders = append(reminders, value)
-		return true
-	})
-	return reminders
+	return rm.reminds.list()
 }
 
-func (rm *defaultRemindManager) CompleteReminds(ids ...int64) {
-	for _, id := range ids {
-		if complete, ok := rm.completes.Get(id); ok {
-			complete <- true
-			rm.reminds.Delete(id)
+// ClaimReminds leases the reminds that are not being delivered at the moment.
+// They are offered again if not completed before the lease expires. The
+// claimant is not kept in memory, any poller may complete a leased remind.
+func (rm *defaultRemindManager) ClaimReminds(
+	_ string,
+	lease time.Duration,
+) []models.Remind {
+	return rm.reminds.claim(lease)
+}
+
+func (rm *defaultRemindManager) CompleteReminds(occurrenceIDs ...string) {
+	rm.reminds.complete(occurrenceIDs...)
+}
+
+// FailRemind moves an undelivered remind to the dead letters. It reports
+// whether the remind was pending.
+func (rm *defaultRemindManager) FailRemind(occurrenceID string, reason string) bool {
+	return rm.reminds.fail(occurrenceID, reason)
+}
+
+func (rm *defaultRemindManager) GetDeadReminds() []models.DeadRemind {
+	return rm.reminds.listDead()
+}
+
+// ReplayReminds makes the dead reminds available for delivery again.
+func (rm *defaultRemindManager) ReplayReminds(occurrenceIDs ...string) {
+	rm.reminds.replay(occurrenceIDs...)
+	rm.updates.broadcast()
+}
+
+func (rm *defaultRemindManager) DiscardDeadReminds(occurrenceIDs ...string) {
+	rm.reminds.discard(occurrenceIDs...)
+}
+
+// Subscribe returns a channel signalled whenever new reminds become
+// available for delivery. The subscription is released by calling cancel.
+func (rm *defaultRemindManager) Subscribe() (<-chan struct{}, func()) {
+	return rm.updates.subscribe()
+}
+
+// AddReminders schedules the reminders catching up the runs missed since
+// their last run. The runs fired before a restart whose reminds were not
+// handled are fired again regardless of the catch-up policy.
+func (rm *defaultRemindManager) AddReminders(reminders ...models.Reminder) {
+	rm.addReminders(true, reminders...)
+}
+
+// RescheduleReminders replaces the schedule of the edited reminders and
+// returns their next runs. The runs of the new rules that passed since the
+// last run are not caught up.
+func (rm *defaultRemindManager) RescheduleReminders(
+	reminders ...models.Reminder,
+) map[int64]time.Time {
+	scheduled := rm.addReminders(false, reminders...)
+
+	nextRuns := make(map[int64]time.Time, len(scheduled))
+	rm.mu.Lock()
+	for _, s := range scheduled {
+		if rm.scheduled[s.reminder.ID] == s && !s.nextRun.IsZero() {
+			nextRuns[s.reminder.ID] = s.nextRun
 		}
 	}
+	rm.mu.Unlock()
+	return nextRuns
 }
 
-func (rm *defaultRemindManager) AddReminders(reminders ...models.Reminder) {
+func (rm *defaultRemindManager) addReminders(
+	catchUp bool,
+	reminders ...models.Reminder,
+) []*scheduledReminder {
+	var scheduled []*scheduledReminder
+	now := time.Now()
 	for _, reminder := range reminders {
-		expr, err := cronexpr.Parse(reminder.Rule)
+		if reminder.ArchivedAt.Valid {
+			rm.mu.Lock()
+			if old, ok := rm.scheduled[reminder.ID]; ok && old.index >= 0 {
+				heap.Remove(&rm.queue, old.index)
+			}
+			delete(rm.scheduled, reminder.ID)
+			rm.mu.Unlock()
+			continue
+		}
+
+		expr, err := parseSchedule(rm.schedules, rm.calendars, reminder)
 		if err != nil {
 			log.Err(err).
 				Str("rule", reminder.Rule).
-				Msg("Cannot parse cron expression")
+				Msg("Cannot parse schedule")
 			continue
 		}
 
-		cancel := make(chan bool)
-		complete := make(chan bool)
+		if catchUp {
+			loc := rm.locations.forReminder(reminder)
+			for _, runAt := range undeliveredRuns(reminder, expr, loc) {
+				log.Info().
+					Any("Reminder", reminder).
+					Time("Run time", runAt).
+					Msg("Firing remind lost undelivered on restart")
+				// The runs were counted when they were fired first.
+				rm.fire(reminder, runAt, reminder.Overlap)
+			}
+			missed := missedRuns(reminder, expr, loc, now)
+			for _, runAt := range missed {
+				log.Info().
+					Any("Reminder", reminder).
+					Time("Missed time", runAt).
+					Msg("Catching up missed remind")
+				if rm.fire(reminder, runAt, models.OverlapQueue) {
+					reminder.Occurrences++
+					rm.countRun(reminder.ID)
+				}
+			}
+		}
 
-		rm.cancels.Set(reminder.ID, cancel)
-		rm.completes.Set(reminder.ID, complete)
+		s := &scheduledReminder{reminder: reminder, expr: expr, index: -1}
+		rm.mu.Lock()
+		if old, ok := rm.scheduled[reminder.ID]; ok && old.index >= 0 {
+			heap.Remove(&rm.queue, old.index)
+		}
+		rm.scheduled[reminder.ID] = s
+		rm.mu.Unlock()
 
-		go rm.generateReminds(reminder, expr, cancel, complete)
+		rm.schedule(s, now)
+		scheduled = append(scheduled, s)
 	}
+	rm.notify()
+	return scheduled
 }
 
 func (rm *defaultRemindManager) RemoveReminders(ids ...int64) {
+	rm.mu.Lock()
 	for _, id := range ids {
-		if cancel, ok := rm.cancels.Get(id); ok {
-			select {
-			case cancel <- true:
-			default:
+		if s, ok := rm.scheduled[id]; ok {
+			if s.index >= 0 {
+				heap.Remove(&rm.queue, s.index)
 			}
-			close(cancel)
+			delete(rm.scheduled, id)
+		}
+	}
+	rm.removeSnoozed(ids...)
+	rm.mu.Unlock()
 
-			if complete, ok := rm.completes.Get(id); ok {
-				select {
-				case complete <- true:
-				default:
-				}
-				close(complete)
-			}
+	rm.reminds.removeReminders(ids...)
+	rm.notify()
+}
+
+// RescheduleChannel recomputes the next runs of the reminders of the channel
+// after its time zone changed and returns them. Reminders with their own time
+// zone are not affected.
+func (rm *defaultRemindManager) RescheduleChannel(channel string) map[int64]time.Time {
+	rm.locations.forget(channel)
+
+	var affected []*scheduledReminder
+	rm.mu.Lock()
+	for _, s := range rm.scheduled {
+		if s.reminder.Channel == channel && !s.reminder.TimeZone.Valid {
+			affected = append(affected, s)
+		}
+	}
+	rm.mu.Unlock()
+
+	now := time.Now()
+	for _, s := range affected {
+		rm.schedule(s, now)
+	}
+	rm.notify()
+
+	nextRuns := make(map[int64]time.Time, len(affected))
+	rm.mu.Lock()
+	for _, s := range affected {
+		if rm.scheduled[s.reminder.ID] == s && !s.nextRun.IsZero() {
+			nextRuns[s.reminder.ID] = s.nextRun
+		}
+	}
+	rm.mu.Unlock()
+	return nextRuns
+}
+
+// RescheduleCalendar recomputes the next runs of the reminders using the
+// calendar after its dates changed and returns them.
+func (rm *defaultRemindManager) RescheduleCalendar(calendarID int64) map[int64]time.Time {
+	rm.calendars.forget(calendarID)
+
+	var affected []*scheduledReminder
+	rm.mu.Lock()
+	for _, s := range rm.scheduled {
+		if s.reminder.CalendarID.Valid && s.reminder.CalendarID.Int64 == calendarID {
+			affected = append(affected, s)
+		}
+	}
+	rm.mu.Unlock()
+
+	now := time.Now()
+	for _, s := range affected {
+		rm.schedule(s, now)
+	}
+	rm.notify()
 
-			rm.reminds.Delete(id)
-			rm.completes.Delete(id)
-			rm.cancels.Delete(id)
+	nextRuns := make(map[int64]time.Time, len(affected))
+	rm.mu.Lock()
+	for _, s := range affected {
+		if rm.scheduled[s.reminder.ID] == s && !s.nextRun.IsZero() {
+			nextRuns[s.reminder.ID] = s.nextRun
 		}
 	}
+	rm.mu.Unlock()
+	return nextRuns
 }
 
 func (rm *defaultRemindManager) UpdateReminderOwner(id int64, owner string) {
-	rm.reminds.Apply(id, func(remind models.Remind) models.Remind {
+	rm.mu.Lock()
+	if s, ok := rm.scheduled[id]; ok {
+		s.reminder.Owner = sql.NullString{String: owner, Valid: true}
+	}
+	for i := range rm.snoozed {
+		if rm.snoozed[i].reminder.ID == id {
+			rm.snoozed[i].reminder.Owner = sql.NullString{String: owner, Valid: true}
+		}
+	}
+	rm.mu.Unlock()
+
+	rm.reminds.apply(id, func(remind models.Remind) models.Remind {
 		remind.Owner = sql.NullString{String: owner, Valid: true}
 		return remind
 	})
 }
 
 func (rm *defaultRemindManager) UpdateRemindWebhook(id int64, webhook string) {
-	rm.reminds.Apply(id, func(remind models.Remind) models.Remind {
+	rm.reminds.apply(id, func(remind models.Remind) models.Remind {
 		remind.Webhook = webhook
 		return remind
 	})
 }
 
-func (rm *defaultRemindManager) generateReminds(
-	reminder models.Reminder,
-	expr *cronexpr.Expression,
-	cancel <-chan bool,
-	complete <-chan bool,
-) {
-	log.Info().
-		Str("Reminder", fmt.Sprintf("%v", reminder)).
-		Msg("Starts generating reminds")
+func (rm *defaultRemindManager) notify() {
+	select {
+	case rm.wake <- struct{}{}:
+	default:
+	}
+}
 
+// dispatch sleeps until the earliest scheduled run and fires every reminder
+// that is due. It is woken up earlier whenever the schedule changes.
+func (rm *defaultRemindManager) dispatch() {
+	timer := time.NewTimer(math.MaxInt64)
 	for {
-		now := time.Now().In(rm.defaultLocation)
-		if channel, err := repositories.GetChannel(rm.db, reminder.Channel); err == nil {
-			if loc, err := time.LoadLocation(channel.TimeZone); err == nil {
-				now = now.In(loc)
-			} else {
-				log.Warn().
-					Err(err).
-					Str("Location", loc.String()).
-					Any("Channel", channel).
-					Interface("Default location", rm.defaultLocation).
-					Msg("Cannot parse location, using default TZ")
-			}
-		} else {
-			log.Error().Err(err).Any("Channel", reminder.Channel).Msg("Channel not found in db")
+		rm.mu.Lock()
+		wait := time.Duration(math.MaxInt64)
+		if len(rm.queue) > 0 {
+			wait = time.Until(rm.queue[0].nextRun)
 		}
-
-		nextTime := expr.Next(now).UTC()
-		if nextTime.IsZero() {
-			rm.RemoveReminders(reminder.ID)
-			repositories.DeleteReminder(rm.db, reminder.ID)
-			return
+		if len(rm.snoozed) > 0 {
+			wait = min(wait, time.Until(rm.snoozed[0].runAt))
 		}
+		rm.mu.Unlock()
 
-		timer := time.NewTimer(time.Until(nextTime))
-		log.Info().
-			Any("Reminder", reminder).
-			Time("Next time", nextTime).
-			Msg("Next trigger time calculated")
+		timer.Reset(wait)
 		select {
 		case <-timer.C:
-			go func() {
-				rm.reminds.Set(reminder.ID, rm.reminderToRemind(reminder))
-			}()
-			<-complete
-		case <-cancel:
-			return
+			rm.fireDue(time.Now())
+		case <-rm.wake:
+			timer.Stop()
 		}
 	}
 }
 
-func (rm *defaultRemindManager) reminderToRemind(
+func (rm *defaultRemindManager) fireDue(now time.Time) {
+	type dueRun struct {
+		s        *scheduledReminder
+		reminder models.Reminder
+		runAt    time.Time
+	}
+
+	var due []dueRun
+	rm.mu.Lock()
+	for len(rm.queue) > 0 && !rm.queue[0].nextRun.After(now) {
+		s := heap.Pop(&rm.queue).(*scheduledReminder)
+		due = append(due, dueRun{s: s, reminder: s.reminder, runAt: s.nextRun})
+	}
+	snoozed := rm.popDueSnoozed(now)
+	rm.mu.Unlock()
+
+	for _, run := range due {
+		if rm.fire(run.reminder, run.runAt, run.reminder.Overlap) {
+			rm.mu.Lock()
+			run.s.reminder.Occurrences++
+			rm.mu.Unlock()
+			rm.countRun(run.reminder.ID)
+		}
+		rm.schedule(run.s, now)
+	}
+	for _, s := range snoozed {
+		rm.fireSnoozed(s)
+	}
+}
+
+// schedule computes the run of the reminder following after and puts it into
+// the queue, or moves it there if it is queued already, unless the reminder
+// was removed or replaced in the meantime.
+// Reminders without future runs are finished.
+func (rm *defaultRemindManager) schedule(s *scheduledReminder, after time.Time) {
+	id := s.reminder.ID
+	nextRun, suspended := nextRunAfter(s.reminder, s.expr, rm.locations.forReminder(s.reminder), after)
+	if suspended {
+		rm.suspend(s)
+		return
+	}
+	if nextRun.IsZero() {
+		rm.finish(s)
+		return
+	}
+
+	rm.mu.Lock()
+	if rm.scheduled[id] != s {
+		rm.mu.Unlock()
+		return
+	}
+	persist := !s.reminder.NextRunAt.Valid || !s.reminder.NextRunAt.Time.Equal(nextRun)
+	s.reminder.NextRunAt = sql.NullTime{Time: nextRun, Valid: true}
+	s.nextRun = nextRun
+	if s.index >= 0 {
+		heap.Fix(&rm.queue, s.index)
+	} else {
+		heap.Push(&rm.queue, s)
+	}
+	rm.mu.Unlock()
+
+	log.Debug().
+		Int64("Reminder", id).
+		Time("Next time", nextRun).
+		Msg("Next trigger time calculated")
+
+	if persist {
+		if err := rm.store.updateNextRun(id, nextRun); err != nil {
+			log.Err(err).Int64("Reminder", id).Msg("Cannot persist next run time")
+		}
+	}
+}
+
+// suspend takes the reminder paused indefinitely off the queue, it stays
+// known to the manager until it is resumed.
+func (rm *defaultRemindManager) suspend(s *scheduledReminder) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if rm.scheduled[s.reminder.ID] != s {
+		return
+	}
+	if s.index >= 0 {
+		heap.Remove(&rm.queue, s.index)
+	}
+	s.nextRun = time.Time{}
+
+	log.Debug().Int64("Reminder", s.reminder.ID).Msg("Reminder is paused")
+}
+
+// finish takes the reminder without runs left off the schedule, its
+// undelivered reminds are still delivered. The owner is told about it if the
+// reminder asks for it.
+func (rm *defaultRemindManager) finish(s *scheduledReminder) {
+	rm.mu.Lock()
+	if rm.scheduled[s.reminder.ID] != s {
+		rm.mu.Unlock()
+		return
+	}
+	if s.index >= 0 {
+		heap.Remove(&rm.queue, s.index)
+	}
+	delete(rm.scheduled, s.reminder.ID)
+	rm.mu.Unlock()
+
+	if err := rm.store.finishReminder(s.reminder); err != nil {
+		log.Err(err).Int64("Reminder", s.reminder.ID).Msg("Cannot finish reminder")
+	}
+	if s.reminder.NotifyFinish {
+		notice := finishNotice(s.reminder, time.Now())
+		notice.Webhook = rm.ownerWebhook(s.reminder.Owner)
+		rm.reminds.add(notice, models.OverlapQueue, false)
+		rm.updates.broadcast()
+	}
+}
+
+// fire makes an occurrence of the reminder available for delivery, resolving
+// the overlap with its undelivered occurrences according to the policy. It
+// reports whether the occurrence was queued, dropped ones do not count as runs
+// of the reminder, see countRun. The run is persisted as the last one once its
+// remind is handled.
+func (rm *defaultRemindManager) fire(
 	reminder models.Reminder,
-) models.Remind {
-	remind := models.Remind{
-		ReminderId: reminder.ID,
-		Owner:      reminder.Owner,
-		Name:       reminder.Name,
-		Rule:       reminder.Rule,
-		Channel:    reminder.Channel,
-		Message:    reminder.Message,
+	runAt time.Time,
+	overlap string,
+) bool {
+	queued := rm.reminds.add(rm.reminderToRemind(reminder, runAt), overlap, true)
+	if queued {
+		rm.updates.broadcast()
+	} else {
+		log.Warn().
+			Any("Reminder", reminder).
+			Time("Run time", runAt).
+			Msg("Previous remind is not completed yet, dropping the new one")
 	}
+	return queued
+}
 
-	if reminder.Owner.Valid {
-		user, err := repositories.GetUser(rm.db, reminder.Owner.String)
-		if err == nil && user.Webhook.Valid {
-			remind.Webhook = user.Webhook.String
+// countRun persists a queued run of the reminder towards its occurrences
+// right away, as its remind may be coalesced with the next one before it is
+// handled.
+func (rm *defaultRemindManager) countRun(reminderID int64) {
+	if err := rm.store.addOccurrences(reminderID, 1); err != nil {
+		log.Err(err).Int64("Reminder", reminderID).Msg("Cannot persist occurrences")
+	}
+}
+
+// persistRuns stores the runs whose reminds were completed or moved to the
+// dead letters as the last runs of their reminders, they were counted when
+// fired. The undelivered reminds are kept in memory only, so the runs fired
+// after the last handled one are fired again after a restart.
+func (rm *defaultRemindManager) persistRuns(runs ...models.Remind) {
+	for _, run := range runs {
+		if err := rm.store.updateLastRun(run.ReminderId, run.ScheduledAt, 0); err != nil {
+			log.Err(err).Int64("Reminder", run.ReminderId).Msg("Cannot persist last run time")
 		}
 	}
+}
+
+func (rm *defaultRemindManager) reminderToRemind(
+	reminder models.Reminder,
+	runAt time.Time,
+) models.Remind {
+	return models.Remind{
+		OccurrenceID: newOccurrenceID(),
+		ScheduledAt:  runAt.UTC(),
+		ReminderId:   reminder.ID,
+		Owner:        reminder.Owner,
+		Name:         reminder.Name,
+		Rule:         reminder.Rule,
+		Channel:      reminder.Channel,
+		Message:      reminder.Message,
+		Webhook:      rm.ownerWebhook(reminder.Owner),
+	}
+}
 
-	return remind
+// ownerWebhook returns the webhook the reminds of the owner are posted to,
+// empty if it is not known.
+func (rm *defaultRemindManager) ownerWebhook(owner sql.NullString) string {
+	if !owner.Valid {
+		return ""
+	}
+	webhook, err := rm.store.userWebhook(owner.String)
+	if err != nil || !webhook.Valid {
+		return ""
+	}
+	return webhook.String
 }
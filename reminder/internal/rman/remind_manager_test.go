@@ -83,11 +83,14 @@ func (s *TestSuite) TestBasicOperations() {
 	s.Run("TriggerReminds and GetReminds", func() {
 		rm := rman.New(s.db, location)
 		remind := models.Remind{
-			ReminderId: 1,
-			Name:       "Test Remind",
-			Rule:       "* * * * *",
-			Channel:    "test-channel",
-			Message:    "Test message",
+			OccurrenceID:   "occurrence",
+			IdempotencyKey: "key",
+			ScheduledAt:    time.Now().UTC(),
+			ReminderId:     1,
+			Name:           "Test Remind",
+			Rule:           "* * * * *",
+			Channel:        "test-channel",
+			Message:        "Test message",
 		}
 
 		rm.TriggerReminds(remind)
@@ -108,7 +111,9 @@ func (s *TestSuite) TestBasicOperations() {
 		}
 
 		rm.AddReminders(reminder)
-		rm.CompleteReminds(reminder.ID)
+		for _, remind := range rm.GetReminds() {
+			rm.CompleteReminds(remind.OccurrenceID)
+		}
 
 		reminds := rm.GetReminds()
 		s.Empty(reminds)
@@ -219,7 +224,7 @@ func (s *TestSuite) TestConcurrentAccess() {
 		for _, r := range reminds {
 			remind := r
 			go func() {
-				rm.CompleteReminds(remind.ReminderId)
+				rm.CompleteReminds(remind.OccurrenceID)
 				wg.Done()
 			}()
 		}
@@ -232,3 +237,39 @@ func (s *TestSuite) TestConcurrentAccess() {
 		s.Empty(remainingReminds)
 	})
 }
+
+func (s *TestSuite) TestDatabaseRemindManager() {
+	location, err := time.LoadLocation("UTC")
+	s.Require().NoError(err)
+
+	s.Run("claim, fail and replay", func() {
+		rm := rman.NewDatabase(s.db, location)
+		remind := models.Remind{
+			OccurrenceID: "db-occurrence",
+			ScheduledAt:  time.Now().UTC().Truncate(time.Second),
+			ReminderId:   1,
+			Name:         "Test Remind",
+			Rule:         "* * * * *",
+			Channel:      "test-channel",
+			Message:      "Test message",
+		}
+
+		rm.TriggerReminds(remind)
+		defer rm.RemoveReminders(remind.ReminderId)
+
+		claimed := rm.ClaimReminds("first", time.Minute)
+		s.Require().Len(claimed, 1)
+		s.Equal(remind.OccurrenceID, claimed[0].OccurrenceID)
+		s.Empty(rm.ClaimReminds("second", time.Minute))
+
+		s.True(rm.FailRemind(remind.OccurrenceID, "failed"))
+		s.Empty(rm.GetReminds())
+		s.Len(rm.GetDeadReminds(), 1)
+
+		rm.ReplayReminds(remind.OccurrenceID)
+		s.Len(rm.ClaimReminds("second", time.Minute), 1)
+
+		rm.CompleteReminds(remind.OccurrenceID)
+		s.Empty(rm.GetReminds())
+	})
+}
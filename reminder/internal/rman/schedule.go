@@ -0,0 +1,56 @@
+package rman
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/syncmap"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+// boundedSchedule leaves out the runs of the base schedule before the start
+// and after the end of a reminder.
+type boundedSchedule struct {
+	base         schedule.Schedule
+	starts, ends sql.NullTime
+}
+
+func withBounds(base schedule.Schedule, reminder models.Reminder) schedule.Schedule {
+	if !reminder.StartsAt.Valid && !reminder.EndsAt.Valid {
+		return base
+	}
+	return boundedSchedule{base: base, starts: reminder.StartsAt, ends: reminder.EndsAt}
+}
+
+func (s boundedSchedule) Next(from time.Time) time.Time {
+	if s.starts.Valid && from.Before(s.starts.Time) {
+		from = s.starts.Time.Add(-time.Nanosecond).In(from.Location())
+	}
+	next := s.base.Next(from)
+	if s.ends.Valid && next.After(s.ends.Time) {
+		return time.Time{}
+	}
+	return next
+}
+
+// parseSchedule parses the rule of the reminder according to its schedule
+// type, reusing schedules already parsed for other reminders since many
+// reminders share the same rule. The dates excluded by the calendar of the
+// reminder and the runs out of its bounds are left out of the schedule.
+func parseSchedule(
+	schedules *syncmap.Map[string, schedule.Schedule],
+	calendars *calendarCache,
+	reminder models.Reminder,
+) (schedule.Schedule, error) {
+	key := reminder.ScheduleType + " " + reminder.Rule
+	s, ok := schedules.Get(key)
+	if !ok {
+		var err error
+		if s, err = schedule.Parse(reminder.ScheduleType, reminder.Rule); err != nil {
+			return nil, err
+		}
+		schedules.Set(key, s)
+	}
+	return withBounds(withCalendar(s, calendars, reminder), reminder), nil
+}
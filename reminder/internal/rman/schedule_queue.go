@@ -0,0 +1,50 @@
+package rman
+
+import (
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+type scheduledReminder struct {
+	reminder models.Reminder
+	expr     schedule.Schedule
+	nextRun  time.Time
+	index    int
+}
+
+// scheduleQueue is a min-heap of scheduled reminders ordered by their next
+// run time. It implements heap.Interface.
+type scheduleQueue []*scheduledReminder
+
+func (q scheduleQueue) Len() int { return len(q) }
+
+func (q scheduleQueue) Less(i, j int) bool {
+	if q[i].nextRun.Equal(q[j].nextRun) {
+		return q[i].reminder.ID < q[j].reminder.ID
+	}
+	return q[i].nextRun.Before(q[j].nextRun)
+}
+
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x any) {
+	s := x.(*scheduledReminder)
+	s.index = len(*q)
+	*q = append(*q, s)
+}
+
+func (q *scheduleQueue) Pop() any {
+	old := *q
+	n := len(old)
+	s := old[n-1]
+	old[n-1] = nil
+	s.index = -1
+	*q = old[:n-1]
+	return s
+}
@@ -0,0 +1,665 @@
+package rman
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is a store without persistence, channels have no time zones
+// and users have no webhooks.
+type memoryStore struct{}
+
+func (memoryStore) channelTimeZone(string) (string, error) {
+	return "", errors.New("channel not found")
+}
+
+func (memoryStore) userWebhook(string) (sql.NullString, error) {
+	return sql.NullString{}, nil
+}
+
+func (memoryStore) updateLastRun(int64, time.Time, int) error { return nil }
+
+func (memoryStore) addOccurrences(int64, int) error { return nil }
+
+func (memoryStore) updateNextRun(int64, time.Time) error { return nil }
+
+func (memoryStore) finishReminder(models.Reminder) error { return nil }
+
+func (memoryStore) calendarDates(int64) ([]models.CalendarDate, error) { return nil, nil }
+
+func (memoryStore) calendarWeekend(int64) (string, error) { return models.DefaultWeekend, nil }
+
+// zoneStore is a memory store holding the time zones of channels.
+type zoneStore struct {
+	memoryStore
+	zones map[string]string
+}
+
+func (s zoneStore) channelTimeZone(channel string) (string, error) {
+	if zone, ok := s.zones[channel]; ok {
+		return zone, nil
+	}
+	return s.memoryStore.channelTimeZone(channel)
+}
+
+// runStore is a memory store recording the persisted last runs and
+// occurrences.
+type runStore struct {
+	memoryStore
+	mu          sync.Mutex
+	lastRuns    map[int64]time.Time
+	occurrences map[int64]int
+}
+
+func (s *runStore) addOccurrences(id int64, runs int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.occurrences[id] += runs
+	return nil
+}
+
+func (s *runStore) occurrencesOf(id int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.occurrences[id]
+}
+
+func (s *runStore) updateLastRun(id int64, lastRun time.Time, _ int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastRuns[id] = lastRun
+	return nil
+}
+
+func (s *runStore) lastRun(id int64) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastRuns[id]
+}
+
+func testReminders(n int, rules ...string) []models.Reminder {
+	reminders := make([]models.Reminder, n)
+	for i := range reminders {
+		reminders[i] = models.Reminder{
+			ID:      int64(i + 1),
+			Name:    fmt.Sprintf("Reminder %d", i+1),
+			Rule:    rules[i%len(rules)],
+			Channel: "test-channel",
+			Message: "Test message",
+		}
+	}
+	return reminders
+}
+
+func (rm *defaultRemindManager) nextRuns() map[int64]time.Time {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	runs := make(map[int64]time.Time, len(rm.scheduled))
+	for id, s := range rm.scheduled {
+		runs[id] = s.nextRun
+	}
+	return runs
+}
+
+func TestScheduler(t *testing.T) {
+	t.Run("AddReminders schedules next run", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.AddReminders(testReminders(3, "0 0 12 * * * *")...)
+
+		runs := rm.nextRuns()
+		require.Len(t, runs, 3)
+		for _, run := range runs {
+			assert.Equal(t, 12, run.Hour())
+			assert.True(t, run.After(time.Now()))
+		}
+		assert.Equal(t, 3, rm.queue.Len())
+	})
+
+	t.Run("fireDue triggers and reschedules", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.AddReminders(testReminders(2, "0 0 12 * * * *")...)
+
+		firstRun := rm.queue[0].nextRun
+		rm.fireDue(firstRun)
+
+		assert.Len(t, rm.GetReminds(), 2)
+		for _, run := range rm.nextRuns() {
+			assert.Equal(t, firstRun.Add(24*time.Hour), run)
+		}
+	})
+
+	t.Run("overlap policies", func(t *testing.T) {
+		for policy, expected := range map[string]int{
+			models.OverlapQueue:    2,
+			models.OverlapCoalesce: 1,
+			models.OverlapDrop:     1,
+		} {
+			t.Run(policy, func(t *testing.T) {
+				rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+				reminder := testReminders(1, "0 0 12 * * * *")[0]
+				reminder.Overlap = policy
+				rm.AddReminders(reminder)
+
+				firstRun := rm.queue[0].nextRun
+				rm.fireDue(firstRun)
+				rm.fireDue(firstRun.Add(24 * time.Hour))
+
+				reminds := rm.GetReminds()
+				require.Len(t, reminds, expected)
+				switch policy {
+				case models.OverlapCoalesce:
+					assert.Equal(t, firstRun.Add(24*time.Hour), reminds[0].ScheduledAt)
+				case models.OverlapDrop:
+					assert.Equal(t, firstRun, reminds[0].ScheduledAt)
+				}
+
+				for _, remind := range reminds {
+					rm.CompleteReminds(remind.OccurrenceID)
+				}
+				assert.Empty(t, rm.GetReminds())
+			})
+		}
+	})
+
+	t.Run("dropped runs do not count towards max occurrences", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		reminder := testReminders(1, "0 0 12 * * * *")[0]
+		reminder.Overlap = models.OverlapDrop
+		reminder.MaxOccurrences = sql.NullInt64{Int64: 2, Valid: true}
+		rm.AddReminders(reminder)
+
+		firstRun := rm.queue[0].nextRun
+		rm.fireDue(firstRun)
+		rm.fireDue(firstRun.Add(24 * time.Hour))
+		require.Len(t, rm.GetReminds(), 1)
+		require.Contains(t, rm.nextRuns(), reminder.ID)
+
+		rm.CompleteReminds(rm.GetReminds()[0].OccurrenceID)
+		rm.fireDue(firstRun.Add(48 * time.Hour))
+
+		reminds := rm.GetReminds()
+		require.Len(t, reminds, 1)
+		assert.Equal(t, firstRun.Add(48*time.Hour), reminds[0].ScheduledAt)
+		assert.NotContains(t, rm.nextRuns(), reminder.ID)
+	})
+
+	t.Run("coalesced runs are persisted towards max occurrences", func(t *testing.T) {
+		store := &runStore{lastRuns: map[int64]time.Time{}, occurrences: map[int64]int{}}
+		rm := newDefaultRemindManager(store, time.UTC)
+		reminder := testReminders(1, "0 0 12 * * * *")[0]
+		reminder.Overlap = models.OverlapCoalesce
+		reminder.MaxOccurrences = sql.NullInt64{Int64: 3, Valid: true}
+		rm.AddReminders(reminder)
+
+		firstRun := rm.queue[0].nextRun
+		for day := range 3 {
+			rm.fireDue(firstRun.Add(time.Duration(day) * 24 * time.Hour))
+		}
+		reminds := rm.GetReminds()
+		require.Len(t, reminds, 1)
+		assert.Equal(t, 3, store.occurrencesOf(reminder.ID))
+		assert.NotContains(t, rm.nextRuns(), reminder.ID)
+
+		rm.CompleteReminds(reminds[0].OccurrenceID)
+		assert.Equal(t, 3, store.occurrencesOf(reminder.ID), "handled runs were counted when fired")
+	})
+
+	t.Run("occurrences have unique ids", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.TriggerReminds(
+			models.Remind{ReminderId: 1},
+			models.Remind{ReminderId: 1},
+		)
+
+		reminds := rm.GetReminds()
+		require.Len(t, reminds, 2)
+		assert.NotEmpty(t, reminds[0].OccurrenceID)
+		assert.NotEqual(t, reminds[0].OccurrenceID, reminds[1].OccurrenceID)
+
+		rm.CompleteReminds(reminds[0].OccurrenceID)
+		assert.Equal(t, reminds[1:], rm.GetReminds())
+	})
+
+	t.Run("claimed reminds are leased", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.TriggerReminds(models.Remind{ReminderId: 1}, models.Remind{ReminderId: 2})
+
+		assert.Len(t, rm.ClaimReminds("first", 50*time.Millisecond), 2)
+		assert.Empty(t, rm.ClaimReminds("first", time.Minute))
+		assert.Empty(t, rm.ClaimReminds("second", time.Minute))
+
+		time.Sleep(50 * time.Millisecond)
+		reminds := rm.ClaimReminds("second", time.Minute)
+		require.Len(t, reminds, 2)
+
+		rm.CompleteReminds(reminds[0].OccurrenceID)
+		assert.Len(t, rm.GetReminds(), 1)
+	})
+
+	t.Run("coalescing keeps leased reminds", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		reminder := testReminders(1, "0 0 12 * * * *")[0]
+		reminder.Overlap = models.OverlapCoalesce
+		rm.AddReminders(reminder)
+
+		firstRun := rm.queue[0].nextRun
+		rm.fireDue(firstRun)
+		require.Len(t, rm.ClaimReminds("poller", time.Minute), 1)
+		rm.fireDue(firstRun.Add(24 * time.Hour))
+		rm.fireDue(firstRun.Add(48 * time.Hour))
+
+		reminds := rm.GetReminds()
+		require.Len(t, reminds, 2)
+		assert.Equal(t, firstRun, reminds[0].ScheduledAt)
+		assert.Equal(t, firstRun.Add(48*time.Hour), reminds[1].ScheduledAt)
+	})
+
+	t.Run("occurrences keep their idempotency key", func(t *testing.T) {
+		reminder := testReminders(1, "0 0 12 * * * *")[0]
+
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.AddReminders(reminder)
+		firstRun := rm.queue[0].nextRun
+		rm.fireDue(firstRun)
+
+		restarted := newDefaultRemindManager(memoryStore{}, time.UTC)
+		restarted.AddReminders(reminder)
+		restarted.fireDue(firstRun)
+
+		before, after := rm.GetReminds(), restarted.GetReminds()
+		require.Len(t, before, 1)
+		require.Len(t, after, 1)
+		assert.NotEqual(t, before[0].OccurrenceID, after[0].OccurrenceID)
+		assert.Equal(t, models.IdempotencyKey(reminder.ID, firstRun), before[0].IdempotencyKey)
+		assert.Equal(t, before[0].IdempotencyKey, after[0].IdempotencyKey)
+	})
+
+	t.Run("Reconcile adds, reschedules and cancels reminders", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		reminders := testReminders(3, "0 0 12 * * * *")
+		rm.AddReminders(reminders[:2]...)
+		rm.TriggerReminds(models.Remind{ReminderId: 2})
+
+		edited := reminders[0]
+		edited.Rule = "0 0 18 * * * *"
+		edited.ModifiedAt = edited.ModifiedAt.Add(time.Minute)
+		invalid := reminders[2]
+		invalid.ID = 4
+		invalid.Rule = "invalid"
+
+		result := rm.Reconcile(edited, reminders[2], invalid)
+		assert.Equal(t, []int64{3}, result.Added)
+		assert.Equal(t, []int64{1}, result.Rescheduled)
+		assert.Equal(t, []int64{2}, result.Cancelled)
+		assert.Equal(t, []int64{4}, result.Invalid)
+
+		runs := rm.nextRuns()
+		assert.Len(t, runs, 2)
+		assert.Equal(t, 18, runs[1].Hour())
+		assert.Empty(t, rm.GetReminds())
+
+		result = rm.Reconcile(edited, reminders[2])
+		assert.Empty(t, result.Added)
+		assert.Empty(t, result.Rescheduled)
+		assert.Empty(t, result.Cancelled)
+	})
+
+	t.Run("Reconcile cancels reminders archived in the database", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		reminders := testReminders(2, "0 0 12 * * * *")
+		rm.AddReminders(reminders...)
+		rm.TriggerReminds(models.Remind{ReminderId: 1})
+
+		archived := reminders[0]
+		archived.ArchivedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+		result := rm.Reconcile(archived, reminders[1])
+		assert.Equal(t, []int64{1}, result.Cancelled)
+		assert.NotContains(t, rm.nextRuns(), archived.ID)
+		assert.Len(t, rm.GetReminds(), 1)
+
+		result = rm.Reconcile(archived, reminders[1])
+		assert.Empty(t, result.Cancelled)
+	})
+
+	t.Run("RescheduleChannel uses the new time zone", func(t *testing.T) {
+		store := zoneStore{zones: map[string]string{}}
+		rm := newDefaultRemindManager(store, time.UTC)
+		reminders := testReminders(2, "0 0 12 * * * *")
+		reminders[1].Channel = "other-channel"
+		rm.AddReminders(reminders...)
+		require.Equal(t, 12, rm.nextRuns()[1].Hour())
+
+		store.zones["test-channel"] = "Asia/Novosibirsk"
+		nextRuns := rm.RescheduleChannel("test-channel")
+
+		require.Len(t, nextRuns, 1)
+		assert.Equal(t, 5, nextRuns[1].Hour())
+		assert.Equal(t, nextRuns[1], rm.nextRuns()[1])
+		assert.Equal(t, 12, rm.nextRuns()[2].Hour())
+		require.Len(t, rm.queue, 2)
+		assert.False(t, rm.queue[0].nextRun.After(rm.queue[1].nextRun))
+	})
+
+	t.Run("reminder time zone takes precedence over channel one", func(t *testing.T) {
+		store := zoneStore{zones: map[string]string{"test-channel": "Asia/Novosibirsk"}}
+		rm := newDefaultRemindManager(store, time.UTC)
+		reminders := testReminders(2, "0 0 12 * * * *")
+		reminders[1].TimeZone = sql.NullString{String: "Europe/Berlin", Valid: true}
+		rm.AddReminders(reminders...)
+
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		runs := rm.nextRuns()
+		assert.Equal(t, 5, runs[1].Hour())
+		assert.Equal(t, 12, runs[2].In(berlin).Hour())
+
+		store.zones["test-channel"] = "UTC"
+		nextRuns := rm.RescheduleChannel("test-channel")
+		assert.Equal(t, []int64{1}, slices.Collect(maps.Keys(nextRuns)))
+		assert.Equal(t, runs[2], rm.nextRuns()[2])
+	})
+
+	t.Run("RescheduleReminders does not catch up the new rule", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		reminders := testReminders(1, "0 0 0 1 1 * 2099")
+		rm.AddReminders(reminders...)
+		require.Equal(t, 2099, rm.nextRuns()[1].Year())
+
+		reminders[0].Rule = "0 * * * * * *"
+		reminders[0].LastRunAt = sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+		nextRuns := rm.RescheduleReminders(reminders[0])
+
+		require.Len(t, nextRuns, 1)
+		assert.WithinDuration(t, time.Now(), nextRuns[1], time.Minute)
+		assert.Equal(t, nextRuns[1], rm.nextRuns()[1])
+		assert.Len(t, rm.queue, 1)
+		assert.Empty(t, rm.GetReminds())
+	})
+
+	t.Run("paused reminders are suspended until resumed", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		reminders := testReminders(1, "0 0 12 * * * *")
+		rm.AddReminders(reminders...)
+		require.Len(t, rm.queue, 1)
+
+		reminders[0].Paused = true
+		nextRuns := rm.RescheduleReminders(reminders[0])
+		assert.Empty(t, nextRuns)
+		assert.Empty(t, rm.queue)
+		assert.Contains(t, rm.scheduled, int64(1))
+
+		until := time.Now().AddDate(0, 0, 3)
+		reminders[0].PausedUntil = sql.NullTime{Time: until, Valid: true}
+		nextRuns = rm.RescheduleReminders(reminders[0])
+		require.Len(t, nextRuns, 1)
+		assert.False(t, nextRuns[1].Before(until))
+		assert.True(t, nextRuns[1].Before(until.AddDate(0, 0, 1)))
+
+		reminders[0].Paused = false
+		reminders[0].PausedUntil = sql.NullTime{}
+		nextRuns = rm.RescheduleReminders(reminders[0])
+		require.Len(t, nextRuns, 1)
+		assert.True(t, nextRuns[1].Before(time.Now().AddDate(0, 0, 1)))
+		assert.Len(t, rm.queue, 1)
+	})
+
+	t.Run("snoozed reminders fire once besides the rule", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		reminders := testReminders(2, "0 0 12 * * * *")
+		rm.AddReminders(reminders...)
+		nextRuns := rm.nextRuns()
+
+		now := time.Now()
+		require.NoError(t, rm.SnoozeReminder(reminders[0], now.Add(time.Hour)))
+		require.NoError(t, rm.SnoozeReminder(reminders[0], now.Add(-time.Minute)))
+		require.NoError(t, rm.SnoozeReminder(reminders[1], now.Add(-time.Second)))
+		rm.RemoveReminders(2)
+		require.Len(t, rm.snoozed, 2)
+
+		rm.fireDue(now)
+		reminds := rm.GetReminds()
+		require.Len(t, reminds, 1)
+		assert.Equal(t, int64(1), reminds[0].ReminderId)
+		assert.Equal(t, now.Add(-time.Minute).UTC(), reminds[0].ScheduledAt)
+		require.Len(t, rm.snoozed, 1)
+		assert.Equal(t, nextRuns[1], rm.nextRuns()[1])
+	})
+
+	t.Run("one-shot reminders fire once and keep their remind", func(t *testing.T) {
+		store := zoneStore{zones: map[string]string{"test-channel": "Asia/Novosibirsk"}}
+		rm := newDefaultRemindManager(store, time.UTC)
+		novosibirsk, err := time.LoadLocation("Asia/Novosibirsk")
+		require.NoError(t, err)
+
+		at := time.Now().Add(time.Hour).In(novosibirsk).Truncate(time.Second)
+		reminders := testReminders(1, at.Format(models.OnceLayout))
+		reminders[0].ScheduleType = models.ScheduleOnce
+		rm.AddReminders(reminders...)
+		require.True(t, at.Equal(rm.nextRuns()[1]))
+
+		rm.fireDue(at)
+		assert.Len(t, rm.GetReminds(), 1)
+		assert.Empty(t, rm.queue)
+		assert.NotContains(t, rm.scheduled, int64(1))
+	})
+
+	t.Run("runs on calendar dates are skipped", func(t *testing.T) {
+		store := &calendarStore{}
+		rm := newDefaultRemindManager(store, time.UTC)
+		reminders := testReminders(1, "0 0 12 * * * *")
+		reminders[0].CalendarID = sql.NullInt64{Int64: 1, Valid: true}
+		rm.AddReminders(reminders...)
+		next := rm.nextRuns()[1]
+
+		store.dates = []models.CalendarDate{{Date: startOfDay(next)}}
+		nextRuns := rm.RescheduleCalendar(1)
+		assert.Equal(t, next.AddDate(0, 0, 1), nextRuns[1])
+		assert.Equal(t, nextRuns[1], rm.nextRuns()[1])
+	})
+
+	t.Run("archived reminders are not scheduled", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		reminders := testReminders(2, "0 0 12 * * * *")
+		reminders[1].ArchivedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		rm.AddReminders(reminders...)
+		assert.Len(t, rm.queue, 1)
+
+		result := rm.Reconcile(reminders...)
+		assert.Empty(t, result.Added)
+		assert.Empty(t, result.Cancelled)
+	})
+
+	t.Run("dead reminds", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.TriggerReminds(models.Remind{ReminderId: 1}, models.Remind{ReminderId: 2})
+		reminds := rm.ClaimReminds("poller", time.Minute)
+		require.Len(t, reminds, 2)
+
+		assert.True(t, rm.FailRemind(reminds[0].OccurrenceID, "404 Not Found"))
+		assert.True(t, rm.FailRemind(reminds[1].OccurrenceID, "400 Bad Request"))
+		assert.False(t, rm.FailRemind("unknown", "error"))
+		assert.Empty(t, rm.GetReminds())
+
+		dead := rm.GetDeadReminds()
+		require.Len(t, dead, 2)
+		assert.Equal(t, reminds[0], dead[0].Remind)
+		assert.Equal(t, "404 Not Found", dead[0].Error)
+
+		rm.ReplayReminds(reminds[0].OccurrenceID)
+		assert.Equal(t, reminds[:1], rm.ClaimReminds("poller", time.Minute))
+
+		rm.DiscardDeadReminds(reminds[1].OccurrenceID)
+		assert.Empty(t, rm.GetDeadReminds())
+	})
+
+	t.Run("runs are persisted once their reminds are handled", func(t *testing.T) {
+		store := &runStore{lastRuns: map[int64]time.Time{}, occurrences: map[int64]int{}}
+		rm := newDefaultRemindManager(store, time.UTC)
+		reminder := testReminders(1, "0 0 12 * * * *")[0]
+		reminder.Overlap = models.OverlapQueue
+		rm.AddReminders(reminder)
+
+		firstRun := rm.queue[0].nextRun
+		rm.fireDue(firstRun)
+		rm.fireDue(firstRun.Add(24 * time.Hour))
+		rm.TriggerReminds(models.Remind{ReminderId: reminder.ID, ScheduledAt: firstRun.Add(48 * time.Hour)})
+		assert.Zero(t, store.lastRun(reminder.ID))
+
+		reminds := rm.GetReminds()
+		require.Len(t, reminds, 3)
+		rm.CompleteReminds(reminds[0].OccurrenceID)
+		assert.Equal(t, firstRun, store.lastRun(reminder.ID))
+
+		rm.FailRemind(reminds[1].OccurrenceID, "500 Internal Server Error")
+		assert.Equal(t, firstRun.Add(24*time.Hour), store.lastRun(reminder.ID))
+
+		rm.CompleteReminds(reminds[2].OccurrenceID)
+		assert.Equal(t, firstRun.Add(24*time.Hour), store.lastRun(reminder.ID), "triggered reminds are not runs")
+	})
+
+	t.Run("runs undelivered before a restart are fired again", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		reminder := testReminders(1, "0 0 12 * * * *")[0]
+		reminder.Overlap = models.OverlapQueue
+		reminder.CatchUp = models.CatchUpSkip
+		rm.AddReminders(reminder)
+
+		nextRun := rm.queue[0].nextRun
+		reminder.NextRunAt = sql.NullTime{Time: nextRun, Valid: true}
+		reminder.LastRunAt = sql.NullTime{Time: nextRun.Add(-72 * time.Hour), Valid: true}
+		reminder.ModifiedAt = nextRun.Add(-96 * time.Hour)
+
+		restarted := newDefaultRemindManager(memoryStore{}, time.UTC)
+		restarted.AddReminders(reminder)
+
+		reminds := restarted.GetReminds()
+		require.Len(t, reminds, 2)
+		assert.Equal(t, nextRun.Add(-48*time.Hour), reminds[0].ScheduledAt)
+		assert.Equal(t, nextRun.Add(-24*time.Hour), reminds[1].ScheduledAt)
+		assert.Equal(t, nextRun, restarted.nextRuns()[reminder.ID])
+	})
+
+	t.Run("subscribers are notified", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		first, cancelFirst := rm.Subscribe()
+		second, cancelSecond := rm.Subscribe()
+		defer cancelSecond()
+
+		rm.TriggerReminds(models.Remind{ReminderId: 1})
+		rm.TriggerReminds(models.Remind{ReminderId: 2})
+		assert.Len(t, first, 1)
+		assert.Len(t, second, 1)
+
+		<-first
+		cancelFirst()
+		rm.AddReminders(testReminders(1, "0 0 12 * * * *")...)
+		rm.fireDue(rm.queue[0].nextRun)
+		assert.Empty(t, first)
+		assert.Len(t, second, 1)
+	})
+
+	t.Run("RemoveReminders unschedules", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.AddReminders(testReminders(3, "0 0 12 * * * *")...)
+		rm.RemoveReminders(1, 3)
+
+		runs := rm.nextRuns()
+		assert.Len(t, runs, 1)
+		assert.Contains(t, runs, int64(2))
+		assert.Equal(t, 1, rm.queue.Len())
+	})
+
+	t.Run("reminder without future runs is removed", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.AddReminders(testReminders(1, "0 0 12 * * * 2001")...)
+
+		assert.Empty(t, rm.nextRuns())
+		assert.Zero(t, rm.queue.Len())
+	})
+
+	t.Run("dispatcher fires due reminders", func(t *testing.T) {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		go rm.dispatch()
+		rm.AddReminders(testReminders(10, "* * * * * * *")...)
+
+		assert.Eventually(t, func() bool {
+			return len(rm.GetReminds()) == 10
+		}, 3*time.Second, 10*time.Millisecond)
+	})
+}
+
+const benchmarkReminders = 100_000
+
+// benchmarkRules are the rules spread over the benchmark reminders: every
+// minute of 12:00-12:59 on workdays.
+var benchmarkRules = func() []string {
+	rules := make([]string, 60)
+	for i := range rules {
+		rules[i] = fmt.Sprintf("0 %d 12 * * MON-FRI *", i)
+	}
+	return rules
+}()
+
+func silenceLogs(tb testing.TB) {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+	tb.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+}
+
+func BenchmarkAddReminders(b *testing.B) {
+	silenceLogs(b)
+	reminders := testReminders(benchmarkReminders, benchmarkRules...)
+	b.ResetTimer()
+	for range b.N {
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.AddReminders(reminders...)
+	}
+}
+
+func BenchmarkFireDue(b *testing.B) {
+	silenceLogs(b)
+	rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+	rm.AddReminders(testReminders(benchmarkReminders, benchmarkRules...)...)
+	b.ResetTimer()
+	for range b.N {
+		rm.fireDue(rm.queue[0].nextRun.Add(time.Hour))
+
+		b.StopTimer()
+		ids := make([]string, 0, benchmarkReminders)
+		for _, remind := range rm.GetReminds() {
+			ids = append(ids, remind.OccurrenceID)
+		}
+		rm.CompleteReminds(ids...)
+		b.StartTimer()
+	}
+}
+
+func BenchmarkRemoveReminders(b *testing.B) {
+	silenceLogs(b)
+	reminders := testReminders(benchmarkReminders, benchmarkRules...)
+	ids := make([]int64, len(reminders))
+	for i, reminder := range reminders {
+		ids[i] = reminder.ID
+	}
+	b.ResetTimer()
+	for range b.N {
+		b.StopTimer()
+		rm := newDefaultRemindManager(memoryStore{}, time.UTC)
+		rm.AddReminders(reminders...)
+		b.StartTimer()
+
+		rm.RemoveReminders(ids...)
+	}
+}
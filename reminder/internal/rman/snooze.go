@@ -0,0 +1,64 @@
+package rman
+
+import (
+	"slices"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/rs/zerolog/log"
+)
+
+// snoozedReminder is a one-off occurrence of the reminder at runAt kept apart
+// from the queue of the rule runs.
+type snoozedReminder struct {
+	reminder models.Reminder
+	runAt    time.Time
+}
+
+// SnoozeReminder fires an extra occurrence of the reminder at runAt. The runs
+// of the reminder's rule are not affected.
+func (rm *defaultRemindManager) SnoozeReminder(reminder models.Reminder, runAt time.Time) error {
+	s := snoozedReminder{reminder: reminder, runAt: runAt.UTC()}
+
+	rm.mu.Lock()
+	i, _ := slices.BinarySearchFunc(rm.snoozed, s.runAt, func(s snoozedReminder, t time.Time) int {
+		return s.runAt.Compare(t)
+	})
+	rm.snoozed = slices.Insert(rm.snoozed, i, s)
+	rm.mu.Unlock()
+
+	log.Debug().
+		Int64("Reminder", reminder.ID).
+		Time("Run time", s.runAt).
+		Msg("Reminder snoozed")
+	rm.notify()
+	return nil
+}
+
+// popDueSnoozed takes the snoozed occurrences due by now off the list. The
+// caller holds the lock.
+func (rm *defaultRemindManager) popDueSnoozed(now time.Time) []snoozedReminder {
+	n := 0
+	for n < len(rm.snoozed) && !rm.snoozed[n].runAt.After(now) {
+		n++
+	}
+	due := slices.Clone(rm.snoozed[:n])
+	rm.snoozed = slices.Delete(rm.snoozed, 0, n)
+	return due
+}
+
+// removeSnoozed drops the snoozed occurrences of the reminders. The caller
+// holds the lock.
+func (rm *defaultRemindManager) removeSnoozed(ids ...int64) {
+	rm.snoozed = slices.DeleteFunc(rm.snoozed, func(s snoozedReminder) bool {
+		return slices.Contains(ids, s.reminder.ID)
+	})
+}
+
+// fireSnoozed makes the snoozed occurrence available for delivery. It never
+// replaces or is replaced by other occurrences and does not count as a run of
+// the rule.
+func (rm *defaultRemindManager) fireSnoozed(s snoozedReminder) {
+	rm.reminds.add(rm.reminderToRemind(s.reminder, s.runAt), models.OverlapQueue, false)
+	rm.updates.broadcast()
+}
@@ -0,0 +1,65 @@
+package rman
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
+)
+
+// store is the persistence the remind manager depends on.
+type store interface {
+	channelTimeZone(channel string) (string, error)
+	userWebhook(user string) (sql.NullString, error)
+	updateLastRun(reminderID int64, lastRun time.Time, runs int) error
+	addOccurrences(reminderID int64, runs int) error
+	updateNextRun(reminderID int64, nextRun time.Time) error
+	finishReminder(reminder models.Reminder) error
+	calendarDates(calendarID int64) ([]models.CalendarDate, error)
+	calendarWeekend(calendarID int64) (string, error)
+}
+
+type dbStore struct {
+	db *sql.DB
+}
+
+func (s dbStore) channelTimeZone(channel string) (string, error) {
+	ch, err := repositories.GetChannel(s.db, channel)
+	if err != nil {
+		return "", err
+	}
+	return ch.TimeZone, nil
+}
+
+func (s dbStore) userWebhook(user string) (sql.NullString, error) {
+	u, err := repositories.GetUser(s.db, user)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return u.Webhook, nil
+}
+
+func (s dbStore) updateLastRun(reminderID int64, lastRun time.Time, runs int) error {
+	return repositories.UpdateReminderLastRun(s.db, reminderID, lastRun, runs)
+}
+
+func (s dbStore) addOccurrences(reminderID int64, runs int) error {
+	return repositories.AddReminderOccurrences(s.db, reminderID, runs)
+}
+
+func (s dbStore) updateNextRun(reminderID int64, nextRun time.Time) error {
+	return repositories.UpdateReminderNextRun(s.db, reminderID, nextRun)
+}
+
+func (s dbStore) finishReminder(reminder models.Reminder) error {
+	return finishReminder(s.db, reminder)
+}
+
+func (s dbStore) calendarDates(calendarID int64) ([]models.CalendarDate, error) {
+	return repositories.GetCalendarDates(s.db, calendarID)
+}
+
+func (s dbStore) calendarWeekend(calendarID int64) (string, error) {
+	return repositories.GetCalendarWeekend(s.db, calendarID)
+}
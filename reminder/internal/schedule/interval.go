@@ -0,0 +1,102 @@
+package schedule
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// intervalLayout is the format of the start of an interval.
+const intervalLayout = "2006-01-02 15:04"
+
+// maxIntervalEvery bounds the count of an interval, so a step of that many
+// weeks still fits in a time.Duration.
+const maxIntervalEvery = int(math.MaxInt64 / (7 * 24 * time.Hour))
+
+var intervalPattern = regexp.MustCompile(
+	`^every\s+(\d+)\s*(h|d|w)\s+from\s+(\d{4}-\d{2}-\d{2})(?:\s+(\d{1,2}:\d{2}))?$`,
+)
+
+// Interval runs every Every hours, days or weeks starting from Start, e.g.
+// `every 2w from 2026-11-04 10:00`. Start is the wall clock time in the time
+// zone of the reminder, the runs of days and weeks keep the time of day across
+// the changes of the offset of the zone.
+type Interval struct {
+	Every int
+	// Unit is one of 'h', 'd' and 'w'.
+	Unit  byte
+	Start time.Time
+}
+
+// ParseInterval parses a rule like `every 10d from 2026-11-04` or
+// `every 2w from 2026-11-04 10:00`, the runs start at midnight if no time is
+// given.
+func ParseInterval(rule string) (Interval, error) {
+	m := intervalPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(rule)))
+	if m == nil {
+		return Interval{}, fmt.Errorf(
+			"invalid interval '%s': expected every N{h|d|w} from YYYY-MM-DD [HH:MM]", rule,
+		)
+	}
+
+	every, err := strconv.Atoi(m[1])
+	if err != nil || every <= 0 {
+		return Interval{}, fmt.Errorf("invalid interval '%s': must be positive", rule)
+	}
+	if every > maxIntervalEvery {
+		return Interval{}, fmt.Errorf("invalid interval '%s': must be at most %d", rule, maxIntervalEvery)
+	}
+	clock := m[4]
+	if clock == "" {
+		clock = "00:00"
+	}
+	if len(clock) == len("0:00") {
+		clock = "0" + clock
+	}
+	start, err := time.Parse(intervalLayout, m[3]+" "+clock)
+	if err != nil {
+		return Interval{}, fmt.Errorf("invalid interval '%s': %w", rule, err)
+	}
+	return Interval{Every: every, Unit: m[2][0], Start: start}, nil
+}
+
+// String formats the interval as a rule ParseInterval reads.
+func (s Interval) String() string {
+	return fmt.Sprintf("every %d%c from %s", s.Every, s.Unit, s.Start.Format(intervalLayout))
+}
+
+func (s Interval) Next(from time.Time) time.Time {
+	start := wallClock(s.Start, from.Location())
+	if from.Before(start) {
+		return start
+	}
+
+	if s.Unit == 'h' {
+		step := time.Duration(s.Every) * time.Hour
+		next := start.Add(from.Sub(start) / step * step)
+		for !next.After(from) {
+			next = next.Add(step)
+		}
+		return next
+	}
+
+	days := s.Every
+	if s.Unit == 'w' {
+		days *= 7
+	}
+	// The calendar days are counted in UTC, so the changes of the offset do
+	// not shorten or lengthen them.
+	elapsed := int(dateOf(from).Sub(dateOf(start)).Hours() / 24)
+	next := start.AddDate(0, 0, elapsed/days*days)
+	for !next.After(from) {
+		next = next.AddDate(0, 0, days)
+	}
+	return next
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,111 @@
+package schedule
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInterval(t *testing.T) {
+	rules := map[string]string{
+		"every 2w from 2026-11-04 10:00":   "every 2w from 2026-11-04 10:00",
+		"Every 10d from 2026-11-04":        "every 10d from 2026-11-04 00:00",
+		"every 36h from 2026-11-04 9:30":   "every 36h from 2026-11-04 09:30",
+		" every 1 w  from 2026-11-04 8:00": "every 1w from 2026-11-04 08:00",
+		"every 15250w from 2026-01-01":     "every 15250w from 2026-01-01 00:00",
+	}
+	for rule, canonical := range rules {
+		t.Run(rule, func(t *testing.T) {
+			interval, err := ParseInterval(rule)
+			require.NoError(t, err)
+			assert.Equal(t, canonical, interval.String())
+		})
+	}
+
+	for _, rule := range []string{
+		"every 0w from 2026-11-04",
+		"every 15251h from 2026-01-01",
+		"every 2251799813685248h from 2026-01-01",
+		"every 3000000000000000h from 2026-01-01",
+		"every 99999999999999999999d from 2026-01-01",
+		"every 2m from 2026-11-04",
+		"every 2w",
+		"every 2w from 2026-13-04",
+		"every 2w from 2026-11-04 25:00",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			_, err := ParseInterval(rule)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestIntervalNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	biweekly, err := Parse(models.ScheduleInterval, "every 2w from 2026-11-04 10:00")
+	require.NoError(t, err)
+
+	t.Run("before the start", func(t *testing.T) {
+		from := time.Date(2026, 10, 18, 12, 0, 0, 0, berlin)
+		assert.Equal(t, time.Date(2026, 11, 4, 10, 0, 0, 0, berlin), biweekly.Next(from))
+	})
+
+	t.Run("every other week", func(t *testing.T) {
+		from := time.Date(2026, 11, 4, 10, 0, 0, 0, berlin)
+		next := biweekly.Next(from)
+		assert.Equal(t, time.Date(2026, 11, 18, 10, 0, 0, 0, berlin), next)
+		assert.Equal(t, time.Date(2026, 12, 2, 10, 0, 0, 0, berlin), biweekly.Next(next))
+	})
+
+	t.Run("later on the day of a run", func(t *testing.T) {
+		from := time.Date(2026, 11, 18, 11, 0, 0, 0, berlin)
+		assert.Equal(t, time.Date(2026, 12, 2, 10, 0, 0, 0, berlin), biweekly.Next(from))
+	})
+
+	t.Run("time of day is kept across offset changes", func(t *testing.T) {
+		daily, err := Parse(models.ScheduleInterval, "every 10d from 2027-03-20 09:00")
+		require.NoError(t, err)
+		from := time.Date(2027, 3, 25, 0, 0, 0, 0, berlin)
+		assert.Equal(t, time.Date(2027, 3, 30, 9, 0, 0, 0, berlin), daily.Next(from))
+	})
+
+	t.Run("hours", func(t *testing.T) {
+		hourly, err := Parse(models.ScheduleInterval, "every 36h from 2026-11-04 00:00")
+		require.NoError(t, err)
+		from := time.Date(2026, 11, 5, 13, 0, 0, 0, time.UTC)
+		assert.Equal(t, time.Date(2026, 11, 7, 0, 0, 0, 0, time.UTC), hourly.Next(from))
+	})
+
+	t.Run("longest interval", func(t *testing.T) {
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		for _, unit := range []string{"h", "d", "w"} {
+			longest, err := Parse(models.ScheduleInterval, fmt.Sprintf("every %d%s from 2026-01-01", maxIntervalEvery, unit))
+			require.NoError(t, err)
+			next := longest.Next(start)
+			assert.True(t, next.After(start), unit)
+			assert.True(t, longest.Next(next).After(next), unit)
+		}
+	})
+}
+
+func TestParse(t *testing.T) {
+	from := time.Date(2026, 11, 4, 15, 0, 0, 0, time.UTC)
+
+	cron, err := Parse(models.ScheduleCron, "0 12 * * *")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 11, 5, 12, 0, 0, 0, time.UTC), cron.Next(from))
+
+	once, err := Parse(models.ScheduleOnce, "2026-11-10 12:00:00")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 11, 10, 12, 0, 0, 0, time.UTC), once.Next(from))
+	assert.True(t, once.Next(time.Date(2026, 11, 11, 0, 0, 0, 0, time.UTC)).IsZero())
+
+	_, err = Parse("weekly", "0 12 * * *")
+	assert.Error(t, err)
+}
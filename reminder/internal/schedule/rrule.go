@@ -0,0 +1,569 @@
+package schedule
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rruleHorizon bounds how many years past from the runs of a recurrence rule
+// are looked for, so a rule matching no date, like the 30th of February, has
+// no runs instead of looping forever.
+const rruleHorizon = 100
+
+// rruleLayouts are the formats of DTSTART, UNTIL and EXDATE values: a floating
+// date and time in the time zone of the reminder, a date and time in UTC and a
+// date.
+const (
+	rruleFloatingLayout = "20060102T150405"
+	rruleUTCLayout      = "20060102T150405Z"
+	rruleDateLayout     = "20060102"
+)
+
+type frequency int
+
+const (
+	daily frequency = iota
+	weekly
+	monthly
+	yearly
+)
+
+var frequencies = map[string]frequency{
+	"DAILY":   daily,
+	"WEEKLY":  weekly,
+	"MONTHLY": monthly,
+	"YEARLY":  yearly,
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// weekdayNum is a BYDAY value, the n-th weekday of the month or the year if
+// n is set, counted from the end if it is negative, or every such weekday.
+type weekdayNum struct {
+	n       int
+	weekday time.Weekday
+}
+
+// moment is a DTSTART, UNTIL or EXDATE value. Floating ones are wall clock
+// times in the time zone of the reminder, date ones cover the whole day.
+type moment struct {
+	t        time.Time
+	floating bool
+	date     bool
+}
+
+// in returns the time of the moment with the floating wall clock in loc.
+func (m moment) in(loc *time.Location) time.Time {
+	if m.floating {
+		return wallClock(m.t, loc)
+	}
+	return m.t
+}
+
+// RRule runs by an RFC 5545 recurrence rule like
+// `RRULE:FREQ=MONTHLY;BYDAY=2TU;BYHOUR=10`, optionally preceded by a DTSTART
+// and followed by EXDATE properties, separated by spaces or line breaks.
+// Rules repeating more often than daily, BYSETPOS, BYYEARDAY and BYWEEKNO are
+// not supported.
+type RRule struct {
+	start    moment
+	startLoc *time.Location
+	freq     frequency
+	interval int
+	count    int
+	until    *moment
+	wkst     time.Weekday
+
+	byMonth    []int
+	byMonthDay []int
+	byDay      []weekdayNum
+	byHour     []int
+	byMinute   []int
+	bySecond   []int
+
+	exdates []moment
+	lines   []string
+}
+
+// IsRRule reports whether the rule looks like a recurrence rule rather than a
+// cron expression.
+func IsRRule(rule string) bool {
+	rule = strings.ToUpper(strings.TrimSpace(rule))
+	return strings.HasPrefix(rule, "RRULE:") || strings.HasPrefix(rule, "DTSTART")
+}
+
+// ParseRRule parses the recurrence rule. A rule without DTSTART starts at
+// now, or at the next whole hour if it has BYHOUR so the minute of now does
+// not end up in its runs. The wall clock of the start is added to the rule as
+// a floating DTSTART so the rule stays the same when it is parsed again.
+func ParseRRule(rule string, now time.Time) (*RRule, error) {
+	r := &RRule{interval: 1, wkst: time.Monday}
+	var hasStart, hasRule bool
+	for _, line := range strings.Fields(rule) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid recurrence rule line '%s': expected NAME:VALUE", line)
+		}
+		name, params, _ := strings.Cut(name, ";")
+		name = strings.ToUpper(name)
+		if name == "RRULE" {
+			value = strings.ToUpper(value)
+		}
+		line = name + ":" + value
+		if params != "" {
+			line = name + ";" + params + ":" + value
+		}
+
+		var err error
+		switch name {
+		case "DTSTART":
+			if hasStart {
+				return nil, fmt.Errorf("invalid recurrence rule: more than one DTSTART")
+			}
+			hasStart = true
+			err = r.parseStart(params, value)
+		case "RRULE":
+			if hasRule {
+				return nil, fmt.Errorf("invalid recurrence rule: more than one RRULE")
+			}
+			hasRule = true
+			err = r.parseRule(value)
+		case "EXDATE":
+			err = r.parseExdates(params, value)
+		default:
+			err = fmt.Errorf("unsupported property %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid recurrence rule: %w", err)
+		}
+		r.lines = append(r.lines, line)
+	}
+	if !hasRule {
+		return nil, fmt.Errorf("invalid recurrence rule '%s': RRULE is missing", rule)
+	}
+	if !hasStart {
+		start := now.Truncate(time.Minute)
+		if len(r.byHour) > 0 {
+			start = time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+			if start.Before(now) {
+				start = start.Add(time.Hour)
+			}
+		}
+		r.start = moment{t: wallClock(start, time.UTC), floating: true}
+		r.lines = append([]string{"DTSTART:" + r.start.t.Format(rruleFloatingLayout)}, r.lines...)
+	}
+	return r, nil
+}
+
+func (r *RRule) parseStart(params string, value string) error {
+	var err error
+	for _, param := range strings.Split(params, ";") {
+		name, v, _ := strings.Cut(param, "=")
+		if strings.EqualFold(name, "TZID") {
+			if r.startLoc, err = time.LoadLocation(v); err != nil {
+				return fmt.Errorf("DTSTART: %w", err)
+			}
+		}
+	}
+	if r.start, err = parseMoment(value); err != nil {
+		return fmt.Errorf("DTSTART: %w", err)
+	}
+	// A start date alone starts the rule at midnight.
+	r.start.date = false
+	return nil
+}
+
+func (r *RRule) parseExdates(params string, value string) error {
+	var loc *time.Location
+	for _, param := range strings.Split(params, ";") {
+		name, v, _ := strings.Cut(param, "=")
+		if strings.EqualFold(name, "TZID") {
+			var err error
+			if loc, err = time.LoadLocation(v); err != nil {
+				return fmt.Errorf("EXDATE: %w", err)
+			}
+		}
+	}
+	for _, v := range strings.Split(value, ",") {
+		m, err := parseMoment(v)
+		if err != nil {
+			return fmt.Errorf("EXDATE: %w", err)
+		}
+		if loc != nil && m.floating && !m.date {
+			m = moment{t: wallClock(m.t, loc)}
+		}
+		r.exdates = append(r.exdates, m)
+	}
+	return nil
+}
+
+func parseMoment(value string) (moment, error) {
+	if t, err := time.Parse(rruleUTCLayout, value); err == nil {
+		return moment{t: t}, nil
+	}
+	if t, err := time.Parse(rruleFloatingLayout, value); err == nil {
+		return moment{t: t, floating: true}, nil
+	}
+	if t, err := time.Parse(rruleDateLayout, value); err == nil {
+		return moment{t: t, floating: true, date: true}, nil
+	}
+	return moment{}, fmt.Errorf("invalid date '%s': expected YYYYMMDD or YYYYMMDDTHHMMSS[Z]", value)
+}
+
+func (r *RRule) parseRule(value string) error {
+	var hasFreq bool
+	for _, part := range strings.Split(value, ";") {
+		name, v, ok := strings.Cut(part, "=")
+		if !ok || v == "" {
+			return fmt.Errorf("invalid rule part '%s': expected NAME=VALUE", part)
+		}
+
+		var err error
+		switch name {
+		case "FREQ":
+			if r.freq, hasFreq = frequencies[v]; !hasFreq {
+				return fmt.Errorf("unsupported FREQ %s: expected DAILY, WEEKLY, MONTHLY or YEARLY", v)
+			}
+		case "INTERVAL":
+			r.interval, err = parsePositive(name, v)
+		case "COUNT":
+			r.count, err = parsePositive(name, v)
+		case "UNTIL":
+			var until moment
+			if until, err = parseMoment(v); err == nil {
+				r.until = &until
+			}
+		case "WKST":
+			var ok bool
+			if r.wkst, ok = weekdays[v]; !ok {
+				err = fmt.Errorf("invalid WKST %s", v)
+			}
+		case "BYMONTH":
+			r.byMonth, err = parseInts(name, v, 1, 12, false)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseInts(name, v, 1, 31, true)
+		case "BYHOUR":
+			r.byHour, err = parseInts(name, v, 0, 23, false)
+		case "BYMINUTE":
+			r.byMinute, err = parseInts(name, v, 0, 59, false)
+		case "BYSECOND":
+			r.bySecond, err = parseInts(name, v, 0, 59, false)
+		case "BYDAY":
+			r.byDay, err = parseWeekdays(v)
+		default:
+			err = fmt.Errorf("unsupported rule part %s", name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case !hasFreq:
+		return fmt.Errorf("FREQ is missing")
+	case r.count > 0 && r.until != nil:
+		return fmt.Errorf("COUNT and UNTIL cannot be used together")
+	case r.freq != monthly && r.freq != yearly && slices.ContainsFunc(r.byDay, func(d weekdayNum) bool {
+		return d.n != 0
+	}):
+		return fmt.Errorf("BYDAY with a number is only allowed with FREQ=MONTHLY or FREQ=YEARLY")
+	case r.freq == weekly && len(r.byMonthDay) > 0:
+		return fmt.Errorf("BYMONTHDAY is not allowed with FREQ=WEEKLY")
+	}
+	return nil
+}
+
+func parsePositive(name string, v string) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %s: expected a positive number", name, v)
+	}
+	return n, nil
+}
+
+// parseInts parses a list of numbers from lo to hi, or from -hi to -lo too if
+// negative is set.
+func parseInts(name string, v string, lo int, hi int, negative bool) ([]int, error) {
+	var values []int
+	for _, s := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(s)
+		abs := n
+		if negative && n < 0 {
+			abs = -n
+		}
+		if err != nil || abs < lo || abs > hi {
+			return nil, fmt.Errorf("invalid %s value '%s'", name, s)
+		}
+		values = append(values, n)
+	}
+	slices.Sort(values)
+	return values, nil
+}
+
+func parseWeekdays(v string) ([]weekdayNum, error) {
+	var days []weekdayNum
+	for _, s := range strings.Split(v, ",") {
+		if len(s) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY value '%s'", s)
+		}
+		weekday, ok := weekdays[s[len(s)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY value '%s'", s)
+		}
+		var n int
+		if prefix := s[:len(s)-2]; prefix != "" {
+			var err error
+			n, err = strconv.Atoi(strings.TrimPrefix(prefix, "+"))
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, fmt.Errorf("invalid BYDAY value '%s'", s)
+			}
+		}
+		days = append(days, weekdayNum{n: n, weekday: weekday})
+	}
+	return days, nil
+}
+
+// String formats the rule as ParseRRule reads it, with its DTSTART.
+func (r *RRule) String() string {
+	return strings.Join(r.lines, " ")
+}
+
+func (r *RRule) Next(from time.Time) time.Time {
+	loc := from.Location()
+	if r.startLoc != nil {
+		loc = r.startLoc
+	}
+	start := r.start.in(loc)
+	var until time.Time
+	if r.until != nil {
+		until = r.until.in(loc)
+		if r.until.date {
+			until = until.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+	}
+
+	// Without COUNT the periods before from are skipped, the runs in them do
+	// not matter.
+	period := 0
+	if r.count == 0 && from.After(start) {
+		period = max(r.periodsBetween(start, from.In(loc))/r.interval-1, 0)
+	}
+
+	occurrences := 0
+	horizon := from.In(loc).Year() + rruleHorizon
+	for {
+		day := r.periodStart(start, period*r.interval)
+		if day.Year() > horizon {
+			return time.Time{}
+		}
+		for _, run := range r.runs(start, day, loc) {
+			if run.Before(start) {
+				continue
+			}
+			occurrences++
+			if r.count > 0 && occurrences > r.count {
+				return time.Time{}
+			}
+			if r.until != nil && run.After(until) {
+				return time.Time{}
+			}
+			if run.After(from) && !r.excluded(run, loc) {
+				return run.In(from.Location())
+			}
+		}
+		period++
+	}
+}
+
+// periodsBetween returns the amount of whole periods of the frequency from
+// start to t.
+func (r *RRule) periodsBetween(start time.Time, t time.Time) int {
+	switch r.freq {
+	case daily:
+		return daysBetween(start, t)
+	case weekly:
+		return daysBetween(r.weekStart(start), r.weekStart(t)) / 7
+	case monthly:
+		return (t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())
+	default:
+		return t.Year() - start.Year()
+	}
+}
+
+// periodStart returns the first day of the period n periods after the one of
+// start, as a date in UTC.
+func (r *RRule) periodStart(start time.Time, n int) time.Time {
+	switch r.freq {
+	case daily:
+		return dateOf(start).AddDate(0, 0, n)
+	case weekly:
+		return dateOf(r.weekStart(start)).AddDate(0, 0, 7*n)
+	case monthly:
+		return time.Date(start.Year(), start.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(start.Year()+n, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+func (r *RRule) weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) - int(r.wkst) + 7) % 7
+	return t.AddDate(0, 0, -offset)
+}
+
+// runs returns the runs of the period starting at day in order.
+func (r *RRule) runs(start time.Time, day time.Time, loc *time.Location) []time.Time {
+	var days []time.Time
+	switch r.freq {
+	case daily:
+		if r.matchesMonth(day) && r.matchesMonthDay(day) && r.matchesWeekday(day, false) {
+			days = append(days, day)
+		}
+	case weekly:
+		for i := 0; i < 7; i++ {
+			d := day.AddDate(0, 0, i)
+			if !r.matchesMonth(d) {
+				continue
+			}
+			if len(r.byDay) == 0 && d.Weekday() != start.Weekday() {
+				continue
+			}
+			if r.matchesWeekday(d, false) {
+				days = append(days, d)
+			}
+		}
+	case monthly:
+		if r.matchesMonth(day) {
+			days = r.monthDays(start, day, false)
+		}
+	default:
+		for month := time.January; month <= time.December; month++ {
+			d := time.Date(day.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+			switch {
+			case len(r.byMonth) > 0:
+				if r.matchesMonth(d) {
+					days = append(days, r.monthDays(start, d, false)...)
+				}
+			case len(r.byMonthDay) > 0 || len(r.byDay) > 0:
+				days = append(days, r.monthDays(start, d, true)...)
+			case month == start.Month():
+				days = append(days, r.monthDays(start, d, false)...)
+			}
+		}
+	}
+
+	hours := orDefault(r.byHour, start.Hour())
+	minutes := orDefault(r.byMinute, start.Minute())
+	seconds := orDefault(r.bySecond, start.Second())
+	var runs []time.Time
+	for _, d := range days {
+		for _, hour := range hours {
+			for _, minute := range minutes {
+				for _, second := range seconds {
+					runs = append(runs, time.Date(d.Year(), d.Month(), d.Day(), hour, minute, second, 0, loc))
+				}
+			}
+		}
+	}
+	return runs
+}
+
+// monthDays returns the days of the month of first matching BYMONTHDAY and
+// BYDAY, or the day of month of start if there are none. The numbered
+// weekdays are counted within the year if inYear is set.
+func (r *RRule) monthDays(start time.Time, first time.Time, inYear bool) []time.Time {
+	var days []time.Time
+	for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+		if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+			if d.Day() == start.Day() {
+				days = append(days, d)
+			}
+			continue
+		}
+		if r.matchesMonthDay(d) && r.matchesWeekday(d, inYear) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+func (r *RRule) matchesMonth(d time.Time) bool {
+	return len(r.byMonth) == 0 || slices.Contains(r.byMonth, int(d.Month()))
+}
+
+func (r *RRule) matchesMonthDay(d time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	last := daysIn(d.Year(), d.Month())
+	for _, day := range r.byMonthDay {
+		if day == d.Day() || day < 0 && last+day+1 == d.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday checks the day against BYDAY, numbered weekdays are counted
+// within the month of the day, or within its year if inYear is set.
+func (r *RRule) matchesWeekday(d time.Time, inYear bool) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	index, total := d.Day(), daysIn(d.Year(), d.Month())
+	if inYear {
+		index, total = d.YearDay(), time.Date(d.Year(), time.December, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	}
+	for _, day := range r.byDay {
+		if day.weekday != d.Weekday() {
+			continue
+		}
+		switch {
+		case day.n == 0,
+			day.n > 0 && (index-1)/7+1 == day.n,
+			day.n < 0 && (total-index)/7+1 == -day.n:
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RRule) excluded(run time.Time, loc *time.Location) bool {
+	for _, exdate := range r.exdates {
+		if exdate.date {
+			if dateOf(run.In(loc)).Equal(dateOf(exdate.t)) {
+				return true
+			}
+			continue
+		}
+		if run.Equal(exdate.in(loc)) {
+			return true
+		}
+	}
+	return false
+}
+
+func orDefault(values []int, def int) []int {
+	if len(values) == 0 {
+		return []int{def}
+	}
+	return values
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func daysBetween(from time.Time, to time.Time) int {
+	return int(dateOf(to).Sub(dateOf(from)).Hours() / 24)
+}
@@ -0,0 +1,170 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runsOf returns up to n runs of the rule following from.
+func runsOf(t *testing.T, rule string, from time.Time, n int) []time.Time {
+	t.Helper()
+	r, err := ParseRRule(rule, from)
+	require.NoError(t, err)
+
+	var runs []time.Time
+	for len(runs) < n {
+		next := r.Next(from)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+		from = next
+	}
+	return runs
+}
+
+func TestRRuleNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	from := time.Date(2026, 10, 18, 12, 0, 0, 0, berlin)
+	at := func(month time.Month, day int, hour int) time.Time {
+		return time.Date(2026, month, day, hour, 0, 0, 0, berlin)
+	}
+
+	// The runs of the rules are checked up to the expected ones, the finite
+	// rules must have no more runs.
+	tests := []struct {
+		name   string
+		rule   string
+		runs   []time.Time
+		finite bool
+	}{
+		{
+			name: "second tuesday of the month",
+			rule: "RRULE:FREQ=MONTHLY;BYDAY=2TU;BYHOUR=10",
+			runs: []time.Time{at(11, 10, 10), at(12, 8, 10), time.Date(2027, 1, 12, 10, 0, 0, 0, berlin)},
+		},
+		{
+			name: "last friday of the month",
+			rule: "DTSTART:20261001T170000 RRULE:FREQ=MONTHLY;BYDAY=-1FR",
+			runs: []time.Time{at(10, 30, 17), at(11, 27, 17), at(12, 25, 17)},
+		},
+		{
+			name: "every other week",
+			rule: "DTSTART:20261104T100000 RRULE:FREQ=WEEKLY;INTERVAL=2",
+			runs: []time.Time{at(11, 4, 10), at(11, 18, 10), at(12, 2, 10)},
+		},
+		{
+			name: "several weekdays and hours",
+			rule: "DTSTART:20261019T000000 RRULE:FREQ=WEEKLY;BYDAY=MO,WE;BYHOUR=9,15",
+			runs: []time.Time{at(10, 19, 9), at(10, 19, 15), at(10, 21, 9), at(10, 21, 15), at(10, 26, 9)},
+		},
+		{
+			name:   "count",
+			rule:   "DTSTART:20261016T090000 RRULE:FREQ=DAILY;COUNT=5",
+			runs:   []time.Time{at(10, 19, 9), at(10, 20, 9)},
+			finite: true,
+		},
+		{
+			name:   "until",
+			rule:   "DTSTART:20261016T090000 RRULE:FREQ=DAILY;UNTIL=20261020",
+			runs:   []time.Time{at(10, 19, 9), at(10, 20, 9)},
+			finite: true,
+		},
+		{
+			name: "exdate",
+			rule: "DTSTART:20261019T090000 RRULE:FREQ=DAILY EXDATE:20261020T090000,20261022",
+			runs: []time.Time{at(10, 19, 9), at(10, 21, 9), at(10, 23, 9)},
+		},
+		{
+			name:   "exdates count towards count",
+			rule:   "DTSTART:20261019T090000 RRULE:FREQ=DAILY;COUNT=3 EXDATE:20261020T090000",
+			runs:   []time.Time{at(10, 19, 9), at(10, 21, 9)},
+			finite: true,
+		},
+		{
+			name: "negative month day",
+			rule: "DTSTART:20261001T080000 RRULE:FREQ=MONTHLY;BYMONTHDAY=-1",
+			runs: []time.Time{at(10, 31, 8), at(11, 30, 8), at(12, 31, 8)},
+		},
+		{
+			name: "yearly",
+			rule: "DTSTART:20261225T080000 RRULE:FREQ=YEARLY",
+			runs: []time.Time{at(12, 25, 8), time.Date(2027, 12, 25, 8, 0, 0, 0, berlin)},
+		},
+		{
+			name: "first monday of the year",
+			rule: "DTSTART:20260101T090000 RRULE:FREQ=YEARLY;BYDAY=1MO",
+			runs: []time.Time{time.Date(2027, 1, 4, 9, 0, 0, 0, berlin)},
+		},
+		{
+			name:   "no matching date",
+			rule:   "DTSTART:20260101T090000 RRULE:FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			runs:   nil,
+			finite: true,
+		},
+		{
+			name:   "start in another time zone",
+			rule:   "DTSTART;TZID=America/New_York:20261019T090000 RRULE:FREQ=DAILY;COUNT=1",
+			runs:   []time.Time{at(10, 19, 15)},
+			finite: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := len(tt.runs)
+			if tt.finite {
+				n++
+			}
+			runs := runsOf(t, tt.rule, from, n)
+			require.Len(t, runs, len(tt.runs))
+			for i, run := range tt.runs {
+				assert.True(t, run.Equal(runs[i]), "run %d: expected %s, got %s", i, run, runs[i])
+			}
+		})
+	}
+}
+
+func TestParseRRule(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 34, 56, 0, time.UTC)
+
+	r, err := ParseRRule("rrule:freq=monthly;byday=2tu;byhour=10", now)
+	require.NoError(t, err)
+	assert.Equal(t, "DTSTART:20261018T130000 RRULE:FREQ=MONTHLY;BYDAY=2TU;BYHOUR=10", r.String())
+	assert.Equal(t, time.Date(2026, 11, 10, 10, 0, 0, 0, time.UTC), r.Next(now))
+
+	again, err := ParseRRule(r.String(), now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, r.String(), again.String())
+
+	r, err = ParseRRule("RRULE:FREQ=WEEKLY;BYDAY=MO", now)
+	require.NoError(t, err)
+	assert.Equal(t, "DTSTART:20261018T123400 RRULE:FREQ=WEEKLY;BYDAY=MO", r.String())
+	assert.Equal(t, time.Date(2026, 10, 19, 12, 34, 0, 0, time.UTC), r.Next(now))
+
+	assert.True(t, IsRRule("RRULE:FREQ=DAILY"))
+	assert.True(t, IsRRule("DTSTART:20261018T120000 RRULE:FREQ=DAILY"))
+	assert.False(t, IsRRule("0 12 * * *"))
+
+	for _, rule := range []string{
+		"RRULE:BYDAY=MO",
+		"RRULE:FREQ=HOURLY",
+		"RRULE:FREQ=DAILY;COUNT=3;UNTIL=20261231",
+		"RRULE:FREQ=DAILY;COUNT=0",
+		"RRULE:FREQ=WEEKLY;BYDAY=2MO",
+		"RRULE:FREQ=MONTHLY;BYDAY=XX",
+		"RRULE:FREQ=MONTHLY;BYMONTHDAY=32",
+		"RRULE:FREQ=MONTHLY;BYSETPOS=1",
+		"RRULE:FREQ=DAILY EXDATE:tomorrow",
+		"EXDATE:20261225",
+		"FREQ=DAILY",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			_, err := ParseRRule(rule, now)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Package schedule computes the runs of the rules of reminders of every
+// schedule type: cron expressions, single dates, intervals anchored to a
+// start date and RFC 5545 recurrence rules.
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/gorhill/cronexpr"
+)
+
+// Schedule computes the runs of a rule. Next returns the run following from
+// in the location of from, or zero if there are no runs left.
+type Schedule interface {
+	Next(from time.Time) time.Time
+}
+
+// Parse parses the rule according to its schedule type, an empty type is
+// cron.
+func Parse(scheduleType string, rule string) (Schedule, error) {
+	switch scheduleType {
+	case models.ScheduleCron, "":
+		expr, err := cronexpr.Parse(rule)
+		if err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case models.ScheduleOnce:
+		at, err := time.Parse(models.OnceLayout, rule)
+		if err != nil {
+			return nil, err
+		}
+		return once{at: at}, nil
+	case models.ScheduleInterval:
+		return ParseInterval(rule)
+	case models.ScheduleRRule:
+		return ParseRRule(rule, time.Now())
+	default:
+		return nil, fmt.Errorf("unknown schedule type '%s'", scheduleType)
+	}
+}
+
+// once runs a single time at the wall clock time, so it follows the time zone
+// of the reminder like cron rules do.
+type once struct {
+	at time.Time
+}
+
+func (s once) Next(from time.Time) time.Time {
+	at := wallClock(s.at, from.Location())
+	if !at.After(from) {
+		return time.Time{}
+	}
+	return at
+}
+
+// wallClock returns the date and time of t in loc.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
+}
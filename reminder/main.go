@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
 
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/controllers"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/services"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -20,6 +22,19 @@ func main() {
 	}
 	defer app.Db.Close()
 
+	if app.Dispatch != nil {
+		go func() {
+			err := services.RunEmbeddedDispatcher(context.Background(), app, *app.Dispatch)
+			if err != nil {
+				log.Err(err).Msg("Embedded dispatcher stopped")
+			}
+		}()
+	}
+
+	if app.ReconcileInterval > 0 {
+		go services.RunReconciler(context.Background(), app, app.ReconcileInterval)
+	}
+
 	router := gin.Default()
 
 	router.Use(func(ctx *gin.Context) {
@@ -33,10 +48,25 @@ func main() {
 	)
 	router.GET("/reminders", controllers.GetReminders)
 	router.POST("/reminders", controllers.CreateReminder)
+	router.PATCH("/reminders/:id", controllers.EditReminder)
+	router.POST("/reminders/:id/pause", controllers.PauseReminder)
+	router.POST("/reminders/:id/resume", controllers.ResumeReminder)
 	router.DELETE("/reminder/:id", controllers.DeleteReminder)
+	router.GET("/reminders/:id/deliveries", controllers.GetDeliveries)
+
+	router.POST("/calendars/:name/import", controllers.ImportCalendar)
 
 	router.GET("/reminders/triggered", controllers.GetTriggeredReminders)
+	router.GET("/reminders/triggered/stream", controllers.StreamTriggeredReminders)
 	router.POST("/reminders/triggered", controllers.CompleteReminds)
+	router.POST("/reminders/deliveries", controllers.ReportDelivery)
+
+	router.GET("/reminders/dead", controllers.GetDeadReminds)
+	router.POST("/reminders/dead", controllers.FailReminds)
+	router.POST("/reminders/dead/replay", controllers.ReplayReminds)
+	router.DELETE("/reminders/dead", controllers.DiscardDeadReminds)
+
+	router.POST("/admin/reconcile", controllers.Reconcile)
 
 	router.POST("/mattermost/reminders", controllers.MattermostReminder)
 
@@ -0,0 +1,4 @@
+ALTER TABLE reminders
+DROP COLUMN last_run_at,
+DROP COLUMN next_run_at,
+DROP COLUMN catch_up;
\ No newline at end of file
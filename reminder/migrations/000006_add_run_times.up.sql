@@ -0,0 +1,4 @@
+ALTER TABLE reminders
+ADD COLUMN last_run_at TIMESTAMP NULL,
+ADD COLUMN next_run_at TIMESTAMP NULL,
+ADD COLUMN catch_up VARCHAR(15) NOT NULL DEFAULT 'latest';
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE reminders DROP COLUMN overlap;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE reminders
+ADD COLUMN overlap VARCHAR(15) NOT NULL DEFAULT 'coalesce';
\ No newline at end of file
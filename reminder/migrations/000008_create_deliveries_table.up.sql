@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS deliveries (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  reminder_id INT NOT NULL,
+  occurrence_id VARCHAR(64) NOT NULL,
+  scheduled_at TIMESTAMP NULL,
+  attempt INT NOT NULL DEFAULT 1,
+  status_code INT,
+  error TEXT,
+  delivered_at TIMESTAMP NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX deliveries_reminder_id (reminder_id, created_at)
+);
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE reminders
+DROP INDEX reminders_next_run_at;
+
+DROP TABLE IF EXISTS reminds;
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS reminds (
+  occurrence_id VARCHAR(64) NOT NULL PRIMARY KEY,
+  reminder_id INT NOT NULL,
+  scheduled_at TIMESTAMP NOT NULL,
+  owner VARCHAR(127),
+  name VARCHAR(255) NOT NULL,
+  rule TEXT NOT NULL,
+  channel VARCHAR(255) NOT NULL,
+  message TEXT NOT NULL,
+  status VARCHAR(15) NOT NULL DEFAULT 'pending',
+  claimant VARCHAR(255),
+  leased_until TIMESTAMP NULL,
+  error TEXT,
+  failed_at TIMESTAMP NULL,
+  INDEX reminds_reminder_id (reminder_id),
+  INDEX reminds_status (status, scheduled_at)
+);
+
+ALTER TABLE reminders
+ADD INDEX reminders_next_run_at (next_run_at);
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE reminders
+MODIFY modified_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE reminders DROP COLUMN time_zone;
\ No newline at end of file
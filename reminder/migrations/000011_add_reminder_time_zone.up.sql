@@ -0,0 +1,2 @@
+ALTER TABLE reminders
+ADD COLUMN time_zone VARCHAR(50) NULL;
\ No newline at end of file
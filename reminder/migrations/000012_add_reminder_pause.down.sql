@@ -0,0 +1,3 @@
+ALTER TABLE reminders
+DROP COLUMN enabled,
+DROP COLUMN paused_until;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE reminders
+ADD COLUMN enabled BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN paused_until TIMESTAMP NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS reminder_snoozes;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS reminder_snoozes (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  reminder_id INT NOT NULL,
+  run_at TIMESTAMP NOT NULL,
+  INDEX reminder_snoozes_run_at (run_at),
+  INDEX reminder_snoozes_reminder_id (reminder_id)
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE reminders
+DROP COLUMN schedule_type,
+DROP COLUMN archived_at;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE reminders
+ADD COLUMN schedule_type VARCHAR(15) NOT NULL DEFAULT 'cron',
+ADD COLUMN archived_at TIMESTAMP NULL;
\ No newline at end of file
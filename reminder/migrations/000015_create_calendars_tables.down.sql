@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS calendar_dates;
+DROP TABLE IF EXISTS calendars;
\ No newline at end of file
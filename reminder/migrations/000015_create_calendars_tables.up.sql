@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS calendars (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  channel VARCHAR(255) NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX calendars_name (name, channel)
+);
+
+CREATE TABLE IF NOT EXISTS calendar_dates (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  calendar_id INT NOT NULL,
+  date DATE NOT NULL,
+  name VARCHAR(255) NOT NULL DEFAULT '',
+  UNIQUE INDEX calendar_dates_date (calendar_id, date)
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE reminders
+DROP COLUMN calendar_id,
+DROP COLUMN holiday_policy;
\ No newline at end of file
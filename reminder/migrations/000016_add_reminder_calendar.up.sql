@@ -0,0 +1,3 @@
+ALTER TABLE reminders
+ADD COLUMN calendar_id INT NULL,
+ADD COLUMN holiday_policy VARCHAR(15) NOT NULL DEFAULT 'skip';
\ No newline at end of file
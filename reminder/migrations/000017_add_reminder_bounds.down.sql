@@ -0,0 +1,6 @@
+ALTER TABLE reminders
+DROP COLUMN starts_at,
+DROP COLUMN ends_at,
+DROP COLUMN max_occurrences,
+DROP COLUMN occurrences,
+DROP COLUMN notify_finish;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE reminders
+ADD COLUMN starts_at TIMESTAMP NULL,
+ADD COLUMN ends_at TIMESTAMP NULL,
+ADD COLUMN max_occurrences INT NULL,
+ADD COLUMN occurrences INT NOT NULL DEFAULT 0,
+ADD COLUMN notify_finish BOOLEAN NOT NULL DEFAULT FALSE;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE calendars
+DROP COLUMN weekend;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE calendars
+ADD COLUMN weekend VARCHAR(31) NOT NULL DEFAULT 'sat,sun';
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE reminders
+ALTER COLUMN catch_up SET DEFAULT 'skip';
\ No newline at end of file
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Holiday policies define what happens to an occurrence of a reminder that
+// falls on a date excluded by its calendar: it is skipped or shifted to the
+// next working day, the one that is neither excluded nor a weekend day of the
+// calendar.
+const (
+	HolidaySkip  = "skip"
+	HolidayShift = "shift"
+)
+
+// DefaultWeekend is the weekend of the calendars that do not set their own.
+const DefaultWeekend = "sat,sun"
+
+// Calendar is a named set of dates the reminders using it are not sent on.
+// Global calendars have no channel and can be used from any channel.
+type Calendar struct {
+	ID      int64          `json:"id"`
+	Name    string         `json:"name"`
+	Channel sql.NullString `json:"channel"`
+	// Weekend lists the days of the week the runs are not shifted to, like
+	// "fri,sat", empty if there are none.
+	Weekend string `json:"weekend"`
+}
+
+// CalendarDate is an excluded date, it is midnight UTC of the date and is
+// matched against the dates of the occurrences in the reminder time zone.
+type CalendarDate struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name"`
+}
+
+func IsValidHolidayPolicy(policy string) bool {
+	switch policy {
+	case HolidaySkip, HolidayShift:
+		return true
+	default:
+		return false
+	}
+}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseWeekend reads the days of a weekend like "fri,sat" or "none", it
+// returns them in the form stored in Calendar.Weekend.
+func ParseWeekend(s string) (string, error) {
+	if strings.EqualFold(s, "none") {
+		return "", nil
+	}
+	var days []time.Weekday
+	for _, name := range strings.Split(s, ",") {
+		day := slices.Index(weekdayNames, strings.ToLower(strings.TrimSpace(name)))
+		if day < 0 {
+			return "", fmt.Errorf(
+				"invalid weekend day '%s': expected one of %s or none",
+				name,
+				strings.Join(weekdayNames, ", "),
+			)
+		}
+		if !slices.Contains(days, time.Weekday(day)) {
+			days = append(days, time.Weekday(day))
+		}
+	}
+	// Shifted runs would have no working day to move to.
+	if len(days) == len(weekdayNames) {
+		return "", fmt.Errorf("invalid weekend '%s': at least one day of the week must be a working one", s)
+	}
+	return FormatWeekend(days), nil
+}
+
+// FormatWeekend returns the days in the form stored in Calendar.Weekend, the
+// week starting on Monday.
+func FormatWeekend(days []time.Weekday) string {
+	names := make([]string, 0, len(days))
+	for _, offset := range []time.Weekday{1, 2, 3, 4, 5, 6, 0} {
+		if slices.Contains(days, offset) {
+			names = append(names, weekdayNames[offset])
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// WeekendDays returns the days of the week of a weekend stored in
+// Calendar.Weekend.
+func WeekendDays(weekend string) []time.Weekday {
+	var days []time.Weekday
+	for _, name := range strings.Split(weekend, ",") {
+		if day := slices.Index(weekdayNames, name); day >= 0 {
+			days = append(days, time.Weekday(day))
+		}
+	}
+	return days
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Delivery is the outcome of an attempt to send a remind to Mattermost.
+type Delivery struct {
+	ID           int64          `json:"id"`
+	ReminderID   int64          `json:"reminder_id"`
+	OccurrenceID string         `json:"occurrence_id"`
+	ScheduledAt  sql.NullTime   `json:"scheduled_at"`
+	Attempt      int            `json:"attempt"`
+	StatusCode   sql.NullInt64  `json:"status_code"`
+	Error        sql.NullString `json:"error"`
+	DeliveredAt  sql.NullTime   `json:"delivered_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+}
@@ -1,13 +1,43 @@
 package models
 
-import "database/sql"
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
 
+// Remind statuses of the reminds stored in the database.
+const (
+	RemindPending = "pending"
+	RemindDead    = "dead"
+)
+
+// Remind is a single occurrence of a reminder waiting to be delivered.
 type Remind struct {
-	ReminderId int64          `json:"id"`
-	Owner      sql.NullString `json:"owner"`
-	Name       string         `json:"name"`
-	Rule       string         `json:"rule"`
-	Channel    string         `json:"channel"`
-	Message    string         `json:"message"`
-	Webhook    string         `json:"webhook"`
+	OccurrenceID string `json:"occurrence_id"`
+	// IdempotencyKey stays the same for the occurrence even if it is
+	// triggered again, e.g. caught up after a restart.
+	IdempotencyKey string         `json:"idempotency_key"`
+	ScheduledAt    time.Time      `json:"scheduled_at"`
+	ReminderId     int64          `json:"id"`
+	Owner          sql.NullString `json:"owner"`
+	Name           string         `json:"name"`
+	Rule           string         `json:"rule"`
+	Channel        string         `json:"channel"`
+	Message        string         `json:"message"`
+	Webhook        string         `json:"webhook"`
+}
+
+// IdempotencyKey identifies the occurrence of the reminder scheduled at the
+// time.
+func IdempotencyKey(reminderID int64, scheduledAt time.Time) string {
+	return fmt.Sprintf("%d@%s", reminderID, scheduledAt.UTC().Format(time.RFC3339))
+}
+
+// DeadRemind is a remind that could not be delivered and is kept aside for
+// inspection and replay.
+type DeadRemind struct {
+	Remind
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
 }
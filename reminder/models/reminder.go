@@ -5,6 +5,36 @@ import (
 	"time"
 )
 
+// Catch-up policies define what happens to the occurrences of a reminder
+// that fell into a downtime of the reminder service.
+const (
+	CatchUpAll    = "all"
+	CatchUpLatest = "latest"
+	CatchUpSkip   = "skip"
+)
+
+// Overlap policies define what happens to a new occurrence of a reminder
+// while the previous one is still undelivered.
+const (
+	OverlapQueue    = "queue"
+	OverlapCoalesce = "coalesce"
+	OverlapDrop     = "drop"
+)
+
+// Schedule types define how the rule of a reminder is interpreted: as a cron
+// expression, as a single date and time in the reminder time zone in the
+// OnceLayout format, as an interval anchored to a start date like
+// `every 2w from 2026-11-04 10:00` or as an RFC 5545 recurrence rule like
+// `RRULE:FREQ=MONTHLY;BYDAY=2TU;BYHOUR=10`.
+const (
+	ScheduleCron     = "cron"
+	ScheduleOnce     = "once"
+	ScheduleInterval = "interval"
+	ScheduleRRule    = "rrule"
+)
+
+const OnceLayout = time.DateTime
+
 type Reminder struct {
 	ID         int64          `json:"id"`
 	Owner      sql.NullString `json:"owner"`
@@ -14,4 +44,75 @@ type Reminder struct {
 	Message    string         `json:"message"`
 	CreatedAt  time.Time      `json:"created_at"`
 	ModifiedAt time.Time      `json:"modified_at"`
+	LastRunAt  sql.NullTime   `json:"last_run_at"`
+	NextRunAt  sql.NullTime   `json:"next_run_at"`
+	CatchUp    string         `json:"catch_up"`
+	Overlap    string         `json:"overlap"`
+	// TimeZone overrides the time zone of the channel if set.
+	TimeZone sql.NullString `json:"time_zone"`
+	// Paused reminders are stored as not enabled. A paused reminder resumes
+	// by itself at PausedUntil if it is set.
+	Paused       bool         `json:"paused"`
+	PausedUntil  sql.NullTime `json:"paused_until"`
+	ScheduleType string       `json:"schedule_type"`
+	// ArchivedAt is set when the reminder has no runs left, archived
+	// reminders are kept but not scheduled.
+	ArchivedAt sql.NullTime `json:"archived_at"`
+	// CalendarID refers to the calendar of the dates the reminder is not
+	// sent on, HolidayPolicy tells what happens to its runs on those dates.
+	CalendarID    sql.NullInt64 `json:"calendar_id"`
+	HolidayPolicy string        `json:"holiday_policy"`
+	// StartsAt and EndsAt bound the runs of the reminder, MaxOccurrences
+	// limits the amount of its runs, Occurrences counts the runs so far.
+	// The reminder is archived once it is past its bounds and, if
+	// NotifyFinish is set, its owner is told about it.
+	StartsAt       sql.NullTime  `json:"starts_at"`
+	EndsAt         sql.NullTime  `json:"ends_at"`
+	MaxOccurrences sql.NullInt64 `json:"max_occurrences"`
+	Occurrences    int64         `json:"occurrences"`
+	NotifyFinish   bool          `json:"notify_finish"`
+}
+
+// PausedAt reports whether the occurrences of the reminder at t are skipped.
+func (r Reminder) PausedAt(t time.Time) bool {
+	return r.Paused && (!r.PausedUntil.Valid || t.Before(r.PausedUntil.Time))
+}
+
+// Bounded reports whether the runs of the reminder are limited by a date or
+// by their amount.
+func (r Reminder) Bounded() bool {
+	return r.EndsAt.Valid || r.MaxOccurrences.Valid
+}
+
+// OccurrencesLeft reports whether the reminder may run again, which is
+// always the case for a reminder without a limit of its runs.
+func (r Reminder) OccurrencesLeft() bool {
+	return !r.MaxOccurrences.Valid || r.Occurrences < r.MaxOccurrences.Int64
+}
+
+func IsValidScheduleType(scheduleType string) bool {
+	switch scheduleType {
+	case ScheduleCron, ScheduleOnce, ScheduleInterval, ScheduleRRule:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsValidCatchUp(policy string) bool {
+	switch policy {
+	case CatchUpAll, CatchUpLatest, CatchUpSkip:
+		return true
+	default:
+		return false
+	}
+}
+
+func IsValidOverlap(policy string) bool {
+	switch policy {
+	case OverlapQueue, OverlapCoalesce, OverlapDrop:
+		return true
+	default:
+		return false
+	}
 }
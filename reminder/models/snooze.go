@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Snooze is a one-off extra occurrence of a reminder, it does not affect the
+// runs of the reminder's rule.
+type Snooze struct {
+	ID         int64     `json:"id"`
+	ReminderID int64     `json:"reminder_id"`
+	RunAt      time.Time `json:"run_at"`
+}
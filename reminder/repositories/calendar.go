@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+func CreateCalendar(db *sql.DB, name string, channel sql.NullString, weekend string) (int64, error) {
+	res, err := db.Exec(
+		`INSERT INTO calendars (name, channel, weekend) VALUES (?, ?, ?)`,
+		name,
+		channel,
+		weekend,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create calendar: execute query: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("create calendar: get inserted id: %w", err)
+	}
+	return id, nil
+}
+
+// FindCalendar looks the calendar up by its name among the calendars of the
+// channel and the global ones, the calendar of the channel goes first.
+func FindCalendar(db *sql.DB, name string, channel string) (*models.Calendar, error) {
+	row := db.QueryRow(
+		"SELECT id, name, channel, weekend FROM calendars "+
+			"WHERE name = ? AND (channel = ? OR channel IS NULL) "+
+			"ORDER BY channel IS NULL LIMIT 1",
+		name,
+		channel,
+	)
+
+	var calendar models.Calendar
+	if err := row.Scan(&calendar.ID, &calendar.Name, &calendar.Channel, &calendar.Weekend); err != nil {
+		return nil, fmt.Errorf("find calendar: scan row: %w", err)
+	}
+	return &calendar, nil
+}
+
+// GetCalendarWeekend returns the weekend of the calendar in the form of
+// models.Calendar.Weekend.
+func GetCalendarWeekend(db *sql.DB, calendarID int64) (string, error) {
+	var weekend string
+	row := db.QueryRow("SELECT weekend FROM calendars WHERE id = ?", calendarID)
+	if err := row.Scan(&weekend); err != nil {
+		return "", fmt.Errorf("get calendar weekend: scan row: %w", err)
+	}
+	return weekend, nil
+}
+
+// UpdateCalendarWeekend sets the weekend of the calendar.
+func UpdateCalendarWeekend(db *sql.DB, calendarID int64, weekend string) error {
+	if _, err := db.Exec(
+		`UPDATE calendars SET weekend = ? WHERE id = ?`,
+		weekend,
+		calendarID,
+	); err != nil {
+		return fmt.Errorf("update calendar weekend: execute query: %w", err)
+	}
+	return nil
+}
+
+// GetCalendars returns the calendars of the channel and the global ones.
+func GetCalendars(db *sql.DB, channel string) ([]models.Calendar, error) {
+	rows, err := db.Query(
+		"SELECT id, name, channel, weekend FROM calendars "+
+			"WHERE channel = ? OR channel IS NULL ORDER BY name, channel IS NULL",
+		channel,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get calendars: execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var calendars []models.Calendar
+	for rows.Next() {
+		var calendar models.Calendar
+		if err := rows.Scan(&calendar.ID, &calendar.Name, &calendar.Channel, &calendar.Weekend); err != nil {
+			return nil, fmt.Errorf("get calendars: scan row: %w", err)
+		}
+		calendars = append(calendars, calendar)
+	}
+	return calendars, rows.Err()
+}
+
+// DeleteCalendar deletes the calendar with its dates. The reminders using it
+// are detached from it and marked modified.
+func DeleteCalendar(db *sql.DB, calendarID int64) error {
+	if _, err := db.Exec(
+		`UPDATE reminders SET calendar_id = NULL, modified_at = CURRENT_TIMESTAMP WHERE calendar_id = ?`,
+		calendarID,
+	); err != nil {
+		return fmt.Errorf("delete calendar: detach reminders: %w", err)
+	}
+	if _, err := db.Exec(`DELETE FROM calendar_dates WHERE calendar_id = ?`, calendarID); err != nil {
+		return fmt.Errorf("delete calendar: delete dates: %w", err)
+	}
+	if _, err := db.Exec(`DELETE FROM calendars WHERE id = ?`, calendarID); err != nil {
+		return fmt.Errorf("delete calendar: execute query: %w", err)
+	}
+	return nil
+}
+
+// AddCalendarDates adds the dates to the calendar, the names of the dates it
+// has already are replaced.
+func AddCalendarDates(db *sql.DB, calendarID int64, dates []models.CalendarDate) error {
+	if len(dates) == 0 {
+		return nil
+	}
+
+	values := make([]string, len(dates))
+	args := make([]any, 0, 3*len(dates))
+	for i, date := range dates {
+		values[i] = "(?, ?, ?)"
+		args = append(args, calendarID, date.Date.Format(time.DateOnly), date.Name)
+	}
+	if _, err := db.Exec(
+		"INSERT INTO calendar_dates (calendar_id, date, name) VALUES "+
+			strings.Join(values, ", ")+
+			" ON DUPLICATE KEY UPDATE name = VALUES(name)",
+		args...,
+	); err != nil {
+		return fmt.Errorf("add calendar dates: execute query: %w", err)
+	}
+	return nil
+}
+
+// DeleteCalendarDates removes the dates from the calendar and returns the
+// amount of dates removed.
+func DeleteCalendarDates(db *sql.DB, calendarID int64, dates []time.Time) (int64, error) {
+	if len(dates) == 0 {
+		return 0, nil
+	}
+
+	days := make([]string, len(dates))
+	for i, date := range dates {
+		days[i] = date.Format(time.DateOnly)
+	}
+	in, args := placeholders(days)
+	res, err := db.Exec(
+		"DELETE FROM calendar_dates WHERE calendar_id = ? AND date IN ("+in+")",
+		append([]any{calendarID}, args...)...,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("delete calendar dates: execute query: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete calendar dates: get affected rows: %w", err)
+	}
+	return rowsAffected, nil
+}
+
+func GetCalendarDates(db Querier, calendarID int64) ([]models.CalendarDate, error) {
+	rows, err := db.Query(
+		`SELECT date, name FROM calendar_dates WHERE calendar_id = ? ORDER BY date`,
+		calendarID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get calendar dates: execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var dates []models.CalendarDate
+	for rows.Next() {
+		var date models.CalendarDate
+		var dateString string
+		if err := rows.Scan(&dateString, &date.Name); err != nil {
+			return nil, fmt.Errorf("get calendar dates: scan row: %w", err)
+		}
+		if date.Date, err = time.Parse(time.DateOnly, dateString); err != nil {
+			return nil, fmt.Errorf("get calendar dates: parse date: %w", err)
+		}
+		dates = append(dates, date)
+	}
+	return dates, rows.Err()
+}
+
+func GetRemindersByCalendar(db *sql.DB, calendarID int64) ([]models.Reminder, error) {
+	return GetRemindersBy(db, "calendar_id", fmt.Sprint(calendarID))
+}
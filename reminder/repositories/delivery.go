@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dtos"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+const deliveryCols = "id, reminder_id, occurrence_id, scheduled_at, attempt, " +
+	"status_code, error, delivered_at, created_at"
+
+func extractDeliveryFromRow(row multiScanner) (*models.Delivery, error) {
+	var delivery models.Delivery
+	var scheduledAtString, deliveredAtString sql.NullString
+	var createdAtString string
+
+	if err := row.Scan(
+		&delivery.ID,
+		&delivery.ReminderID,
+		&delivery.OccurrenceID,
+		&scheduledAtString,
+		&delivery.Attempt,
+		&delivery.StatusCode,
+		&delivery.Error,
+		&deliveredAtString,
+		&createdAtString,
+	); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if delivery.ScheduledAt, err = parseNullTime(scheduledAtString); err != nil {
+		return nil, err
+	}
+	if delivery.DeliveredAt, err = parseNullTime(deliveredAtString); err != nil {
+		return nil, err
+	}
+	if delivery.CreatedAt, err = time.Parse(timestampLayout, createdAtString); err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func CreateDelivery(db *sql.DB, req dtos.DeliveryDTO) (int64, error) {
+	var scheduledAt, deliveredAt sql.NullTime
+	if !req.ScheduledAt.IsZero() {
+		scheduledAt = sql.NullTime{Time: req.ScheduledAt.UTC(), Valid: true}
+	}
+	if req.DeliveredAt != nil {
+		deliveredAt = sql.NullTime{Time: req.DeliveredAt.UTC(), Valid: true}
+	}
+
+	res, err := db.Exec(`
+		INSERT INTO deliveries
+			(reminder_id, occurrence_id, scheduled_at, attempt, status_code, error, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		`,
+		req.ReminderID,
+		req.OccurrenceID,
+		scheduledAt,
+		req.Attempt,
+		sql.NullInt64{Int64: int64(req.StatusCode), Valid: req.StatusCode != 0},
+		sql.NullString{String: req.Error, Valid: req.Error != ""},
+		deliveredAt,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("create delivery: execute query: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("create delivery: get last insert id: %w", err)
+	}
+	return id, nil
+}
+
+// GetDeliveriesByReminder returns the latest delivery attempts of the
+// reminder, newest first.
+func GetDeliveriesByReminder(
+	db *sql.DB,
+	reminderID int64,
+	limit int,
+) ([]models.Delivery, error) {
+	rows, err := db.Query(
+		"SELECT "+deliveryCols+" FROM deliveries WHERE reminder_id = ? "+
+			"ORDER BY created_at DESC, id DESC LIMIT ?",
+		reminderID,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get deliveries: execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.Delivery
+
+	for rows.Next() {
+		delivery, err := extractDeliveryFromRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("get deliveries: scan row: %w", err)
+		}
+
+		deliveries = append(deliveries, *delivery)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,376 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+// remindCols are read joined with the owner so the webhook is always the
+// current one.
+const remindCols = "r.occurrence_id, r.scheduled_at, r.reminder_id, r.owner, r.name, " +
+	"r.rule, r.channel, r.message, u.webhook, r.error, r.failed_at"
+
+const remindFrom = " FROM reminds r LEFT JOIN users u ON u.name = r.owner "
+
+func extractRemindFromRow(row multiScanner) (*models.DeadRemind, error) {
+	var remind models.DeadRemind
+	var scheduledAtString string
+	var webhook, errorString, failedAtString sql.NullString
+
+	if err := row.Scan(
+		&remind.OccurrenceID,
+		&scheduledAtString,
+		&remind.ReminderId,
+		&remind.Owner,
+		&remind.Name,
+		&remind.Rule,
+		&remind.Channel,
+		&remind.Message,
+		&webhook,
+		&errorString,
+		&failedAtString,
+	); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if remind.ScheduledAt, err = time.Parse(timestampLayout, scheduledAtString); err != nil {
+		return nil, err
+	}
+	failedAt, err := parseNullTime(failedAtString)
+	if err != nil {
+		return nil, err
+	}
+	remind.IdempotencyKey = models.IdempotencyKey(remind.ReminderId, remind.ScheduledAt)
+	remind.FailedAt = failedAt.Time
+	remind.Webhook = webhook.String
+	remind.Error = errorString.String
+
+	return &remind, nil
+}
+
+func queryReminds(db Querier, where string, args ...any) ([]models.DeadRemind, error) {
+	rows, err := db.Query(
+		"SELECT "+remindCols+remindFrom+"WHERE "+where+
+			" ORDER BY r.scheduled_at, r.reminder_id",
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var reminds []models.DeadRemind
+
+	for rows.Next() {
+		remind, err := extractRemindFromRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+
+		reminds = append(reminds, *remind)
+	}
+
+	return reminds, rows.Err()
+}
+
+// placeholders returns the placeholders and the arguments of an IN clause.
+func placeholders[T any](values []T) (string, []any) {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", "), args
+}
+
+func CreateRemind(db Querier, remind models.Remind) error {
+	_, err := db.Exec(`
+		INSERT INTO reminds
+			(occurrence_id, reminder_id, scheduled_at, owner, name, rule, channel, message)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+		remind.OccurrenceID,
+		remind.ReminderId,
+		remind.ScheduledAt.UTC(),
+		remind.Owner,
+		remind.Name,
+		remind.Rule,
+		remind.Channel,
+		remind.Message,
+	)
+	if err != nil {
+		return fmt.Errorf("create remind: execute query: %w", err)
+	}
+	return nil
+}
+
+// GetPendingReminds returns the reminds waiting for delivery ordered by their
+// scheduled time.
+func GetPendingReminds(db Querier) ([]models.Remind, error) {
+	dead, err := queryReminds(db, "r.status = ?", models.RemindPending)
+	if err != nil {
+		return nil, fmt.Errorf("get pending reminds: %w", err)
+	}
+
+	reminds := make([]models.Remind, len(dead))
+	for i := range dead {
+		reminds[i] = dead[i].Remind
+	}
+	return reminds, nil
+}
+
+func GetDeadReminds(db Querier) ([]models.DeadRemind, error) {
+	reminds, err := queryReminds(db, "r.status = ?", models.RemindDead)
+	if err != nil {
+		return nil, fmt.Errorf("get dead reminds: %w", err)
+	}
+	return reminds, nil
+}
+
+// GetPendingRemindIDs returns the pending reminds of the reminder, oldest
+// first, and whether each of them is leased at the moment.
+func GetPendingRemindIDs(
+	db Querier,
+	reminderID int64,
+	now time.Time,
+) ([]string, []bool, error) {
+	rows, err := db.Query(
+		"SELECT occurrence_id, leased_until IS NOT NULL AND leased_until > ? "+
+			"FROM reminds WHERE reminder_id = ? AND status = ? "+
+			"ORDER BY scheduled_at, occurrence_id",
+		now.UTC(),
+		reminderID,
+		models.RemindPending,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get pending remind ids: execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	var leased []bool
+
+	for rows.Next() {
+		var id string
+		var isLeased bool
+		if err := rows.Scan(&id, &isLeased); err != nil {
+			return nil, nil, fmt.Errorf("get pending remind ids: scan row: %w", err)
+		}
+
+		ids = append(ids, id)
+		leased = append(leased, isLeased)
+	}
+
+	return ids, leased, rows.Err()
+}
+
+// ClaimReminds leases the pending reminds that are not leased at the moment
+// to the claimant. Reminds locked by concurrent claims are skipped.
+func ClaimReminds(
+	db *sql.DB,
+	claimant string,
+	now time.Time,
+	lease time.Duration,
+) ([]models.Remind, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("claim reminds: begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(
+		"SELECT occurrence_id FROM reminds "+
+			"WHERE status = ? AND (leased_until IS NULL OR leased_until <= ?) "+
+			"FOR UPDATE SKIP LOCKED",
+		models.RemindPending,
+		now.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("claim reminds: lock reminds: %w", err)
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("claim reminds: scan row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim reminds: read rows: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	in, args := placeholders(ids)
+	if _, err := tx.Exec(
+		"UPDATE reminds SET claimant = ?, leased_until = ? WHERE occurrence_id IN ("+in+")",
+		append([]any{claimant, now.Add(lease).UTC()}, args...)...,
+	); err != nil {
+		return nil, fmt.Errorf("claim reminds: lease reminds: %w", err)
+	}
+
+	dead, err := queryReminds(tx, "r.occurrence_id IN ("+in+")", args...)
+	if err != nil {
+		return nil, fmt.Errorf("claim reminds: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("claim reminds: commit: %w", err)
+	}
+
+	reminds := make([]models.Remind, len(dead))
+	for i := range dead {
+		reminds[i] = dead[i].Remind
+	}
+	return reminds, nil
+}
+
+// CountClaimableReminds counts the pending reminds that are not leased at
+// the moment.
+func CountClaimableReminds(db Querier, now time.Time) (int, error) {
+	var count int
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM reminds "+
+			"WHERE status = ? AND (leased_until IS NULL OR leased_until <= ?)",
+		models.RemindPending,
+		now.UTC(),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count claimable reminds: %w", err)
+	}
+	return count, nil
+}
+
+func DeleteReminds(db Querier, occurrenceIDs ...string) error {
+	if len(occurrenceIDs) == 0 {
+		return nil
+	}
+
+	in, args := placeholders(occurrenceIDs)
+	if _, err := db.Exec(
+		"DELETE FROM reminds WHERE occurrence_id IN ("+in+")",
+		args...,
+	); err != nil {
+		return fmt.Errorf("delete reminds: execute query: %w", err)
+	}
+	return nil
+}
+
+// GetRemindReminderIDs returns the reminders that have pending or dead
+// reminds.
+func GetRemindReminderIDs(db Querier) ([]int64, error) {
+	rows, err := db.Query("SELECT DISTINCT reminder_id FROM reminds")
+	if err != nil {
+		return nil, fmt.Errorf("get remind reminder ids: execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("get remind reminder ids: scan row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteRemindsOfReminders deletes both pending and dead reminds of the
+// reminders.
+func DeleteRemindsOfReminders(db Querier, reminderIDs ...int64) error {
+	if len(reminderIDs) == 0 {
+		return nil
+	}
+
+	in, args := placeholders(reminderIDs)
+	if _, err := db.Exec(
+		"DELETE FROM reminds WHERE reminder_id IN ("+in+")",
+		args...,
+	); err != nil {
+		return fmt.Errorf("delete reminds of reminders: execute query: %w", err)
+	}
+	return nil
+}
+
+// FailRemind marks the pending remind dead. It reports whether the remind was
+// pending.
+func FailRemind(
+	db Querier,
+	occurrenceID string,
+	reason string,
+	failedAt time.Time,
+) (bool, error) {
+	res, err := db.Exec(
+		"UPDATE reminds SET status = ?, error = ?, failed_at = ?, "+
+			"claimant = NULL, leased_until = NULL "+
+			"WHERE occurrence_id = ? AND status = ?",
+		models.RemindDead,
+		reason,
+		failedAt.UTC(),
+		occurrenceID,
+		models.RemindPending,
+	)
+	if err != nil {
+		return false, fmt.Errorf("fail remind: execute query: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("fail remind: get affected rows: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// ReplayReminds makes the dead reminds pending again.
+func ReplayReminds(db Querier, occurrenceIDs ...string) error {
+	if len(occurrenceIDs) == 0 {
+		return nil
+	}
+
+	in, args := placeholders(occurrenceIDs)
+	if _, err := db.Exec(
+		"UPDATE reminds SET status = ?, error = NULL, failed_at = NULL "+
+			"WHERE status = ? AND occurrence_id IN ("+in+")",
+		append([]any{models.RemindPending, models.RemindDead}, args...)...,
+	); err != nil {
+		return fmt.Errorf("replay reminds: execute query: %w", err)
+	}
+	return nil
+}
+
+func DeleteDeadReminds(db Querier, occurrenceIDs ...string) error {
+	if len(occurrenceIDs) == 0 {
+		return nil
+	}
+
+	in, args := placeholders(occurrenceIDs)
+	if _, err := db.Exec(
+		"DELETE FROM reminds WHERE status = ? AND occurrence_id IN ("+in+")",
+		append([]any{models.RemindDead}, args...)...,
+	); err != nil {
+		return fmt.Errorf("delete dead reminds: execute query: %w", err)
+	}
+	return nil
+}
+
+func UpdateRemindsOwner(db Querier, reminderID int64, owner string) error {
+	if _, err := db.Exec(
+		"UPDATE reminds SET owner = ? WHERE reminder_id = ?",
+		owner,
+		reminderID,
+	); err != nil {
+		return fmt.Errorf("update reminds owner: execute query: %w", err)
+	}
+	return nil
+}
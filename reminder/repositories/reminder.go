@@ -3,22 +3,40 @@ package repositories
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dtos"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
 )
 
-const reminderCols = "id, owner, name, rule, channel, message, created_at, modified_at"
+const reminderCols = "id, owner, name, rule, channel, message, created_at, modified_at, " +
+	"last_run_at, next_run_at, catch_up, overlap, time_zone, enabled, paused_until, " +
+	"schedule_type, archived_at, calendar_id, holiday_policy, starts_at, ends_at, " +
+	"max_occurrences, occurrences, notify_finish"
+
+const timestampLayout = "2006-01-02 15:04:05"
 
 type multiScanner interface {
 	Scan(dest ...any) error
 }
 
+// Querier is implemented by both *sql.DB and *sql.Tx, so the queries can be
+// run inside a transaction.
+type Querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 func extractReminderFromRow(row multiScanner) (*models.Reminder, error) {
 	var id int64
-	var name, rule, channel, message, createdAtString, modifiedAtString string
-	var owner sql.NullString
+	var name, rule, channel, message, createdAtString, modifiedAtString, catchUp, overlap, scheduleType, holidayPolicy string
+	var owner, lastRunAtString, nextRunAtString, timeZone, pausedUntilString, archivedAtString sql.NullString
+	var startsAtString, endsAtString sql.NullString
+	var enabled, notifyFinish bool
+	var calendarID, maxOccurrences sql.NullInt64
+	var occurrences int64
 
 	if err := row.Scan(
 		&id,
@@ -29,32 +47,106 @@ func extractReminderFromRow(row multiScanner) (*models.Reminder, error) {
 		&message,
 		&createdAtString,
 		&modifiedAtString,
+		&lastRunAtString,
+		&nextRunAtString,
+		&catchUp,
+		&overlap,
+		&timeZone,
+		&enabled,
+		&pausedUntilString,
+		&scheduleType,
+		&archivedAtString,
+		&calendarID,
+		&holidayPolicy,
+		&startsAtString,
+		&endsAtString,
+		&maxOccurrences,
+		&occurrences,
+		&notifyFinish,
 	); err != nil {
 		return nil, err
 	}
 
-	createdAt, err := time.Parse("2006-01-02 15:04:05", createdAtString)
+	createdAt, err := time.Parse(timestampLayout, createdAtString)
+	if err != nil {
+		return nil, err
+	}
+	modifiedAt, err := time.Parse(timestampLayout, modifiedAtString)
+	if err != nil {
+		return nil, err
+	}
+	lastRunAt, err := parseNullTime(lastRunAtString)
 	if err != nil {
 		return nil, err
 	}
-	modifiedAt, err := time.Parse("2006-01-02 15:04:05", modifiedAtString)
+	nextRunAt, err := parseNullTime(nextRunAtString)
+	if err != nil {
+		return nil, err
+	}
+	pausedUntil, err := parseNullTime(pausedUntilString)
+	if err != nil {
+		return nil, err
+	}
+	archivedAt, err := parseNullTime(archivedAtString)
+	if err != nil {
+		return nil, err
+	}
+	startsAt, err := parseNullTime(startsAtString)
+	if err != nil {
+		return nil, err
+	}
+	endsAt, err := parseNullTime(endsAtString)
 	if err != nil {
 		return nil, err
 	}
 
 	return &models.Reminder{
-		ID:         id,
-		Owner:      owner,
-		Name:       name,
-		Rule:       rule,
-		Channel:    channel,
-		Message:    message,
-		CreatedAt:  createdAt,
-		ModifiedAt: modifiedAt,
+		ID:             id,
+		Owner:          owner,
+		Name:           name,
+		Rule:           rule,
+		Channel:        channel,
+		Message:        message,
+		CreatedAt:      createdAt,
+		ModifiedAt:     modifiedAt,
+		LastRunAt:      lastRunAt,
+		NextRunAt:      nextRunAt,
+		CatchUp:        catchUp,
+		Overlap:        overlap,
+		TimeZone:       timeZone,
+		Paused:         !enabled,
+		PausedUntil:    pausedUntil,
+		ScheduleType:   scheduleType,
+		ArchivedAt:     archivedAt,
+		CalendarID:     calendarID,
+		HolidayPolicy:  holidayPolicy,
+		StartsAt:       startsAt,
+		EndsAt:         endsAt,
+		MaxOccurrences: maxOccurrences,
+		Occurrences:    occurrences,
+		NotifyFinish:   notifyFinish,
 	}, nil
 }
 
-func GetReminder(db *sql.DB, reminderID int64) (*models.Reminder, error) {
+func parseNullTime(s sql.NullString) (sql.NullTime, error) {
+	if !s.Valid {
+		return sql.NullTime{}, nil
+	}
+	t, err := time.Parse(timestampLayout, s.String)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: t, Valid: true}, nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func GetReminder(db Querier, reminderID int64) (*models.Reminder, error) {
 	row := db.QueryRow(
 		"SELECT "+reminderCols+" FROM reminders WHERE id = ?",
 		reminderID,
@@ -165,14 +257,219 @@ func UpdateReminderOwner(db *sql.DB, reminderID int64, userName string) error {
 	return nil
 }
 
-func CreateReminder(db *sql.DB, req dtos.ReminderDTO) (int64, error) {
+// UpdateReminder changes the fields set in the patch and resets the next run,
+// so the reminder is rescheduled by its new rule. The calendar set in the
+// patch is resolved to calendarID by the caller.
+func UpdateReminder(
+	db *sql.DB,
+	reminderID int64,
+	patch dtos.ReminderPatchDTO,
+	calendarID sql.NullInt64,
+) error {
+	sets := []string{"modified_at = CURRENT_TIMESTAMP", "next_run_at = NULL"}
+	var args []any
+	if patch.Name != nil {
+		sets = append(sets, "name = ?")
+		args = append(args, *patch.Name)
+	}
+	if patch.Rule != nil {
+		sets = append(sets, "rule = ?", "archived_at = NULL")
+		args = append(args, *patch.Rule)
+	}
+	if patch.ScheduleType != nil {
+		sets = append(sets, "schedule_type = ?")
+		args = append(args, *patch.ScheduleType)
+	}
+	if patch.Message != nil {
+		sets = append(sets, "message = ?")
+		args = append(args, *patch.Message)
+	}
+	if patch.Calendar != nil {
+		sets = append(sets, "calendar_id = ?")
+		args = append(args, calendarID)
+	}
+	if patch.HolidayPolicy != nil {
+		sets = append(sets, "holiday_policy = ?")
+		args = append(args, *patch.HolidayPolicy)
+	}
+	args = append(args, reminderID)
+
+	res, err := db.Exec(
+		`UPDATE reminders SET `+strings.Join(sets, ", ")+` WHERE id = ?`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("update reminder: execute query: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update reminder: get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("update reminder: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// UpdateReminderPause pauses the reminder until pausedUntil, indefinitely if
+// it is not set, or resumes it when enabled is true.
+func UpdateReminderPause(
+	db *sql.DB,
+	reminderID int64,
+	enabled bool,
+	pausedUntil sql.NullTime,
+) error {
+	res, err := db.Exec(
+		`UPDATE reminders SET enabled = ?, paused_until = ?, next_run_at = NULL WHERE id = ?`,
+		enabled,
+		pausedUntil,
+		reminderID,
+	)
+	if err != nil {
+		return fmt.Errorf("update reminder pause: execute query: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update reminder pause: get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("update reminder pause: %w", sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// UpdateReminderNextRun keeps modified_at, the run times are bookkeeping of
+// the scheduler rather than edits of the reminder. So do the other run time
+// updates.
+func UpdateReminderNextRun(db Querier, reminderID int64, nextRun time.Time) error {
+	_, err := db.Exec(
+		`UPDATE reminders SET next_run_at = ?, modified_at = modified_at WHERE id = ?`,
+		nextRun.UTC(),
+		reminderID,
+	)
+	if err != nil {
+		return fmt.Errorf("update reminder next run: execute query: %w", err)
+	}
+	return nil
+}
+
+// AddReminderOccurrences counts the runs of the reminder fired, apart from
+// updating its last run.
+func AddReminderOccurrences(db Querier, reminderID int64, runs int) error {
+	_, err := db.Exec(
+		`UPDATE reminders SET occurrences = occurrences + ?, modified_at = modified_at WHERE id = ?`,
+		runs,
+		reminderID,
+	)
+	if err != nil {
+		return fmt.Errorf("add reminder occurrences: execute query: %w", err)
+	}
+	return nil
+}
+
+// UnscheduleReminder clears the next run of the reminder so it is not fired
+// until it is scheduled again.
+func UnscheduleReminder(db Querier, reminderID int64) error {
+	if _, err := db.Exec(
+		`UPDATE reminders SET next_run_at = NULL, modified_at = modified_at WHERE id = ?`,
+		reminderID,
+	); err != nil {
+		return fmt.Errorf("unschedule reminder: execute query: %w", err)
+	}
+	return nil
+}
+
+// SetReminderNextRunIfUnset persists the next run of a reminder that has not
+// been scheduled yet. It reports whether the next run was set.
+func SetReminderNextRunIfUnset(db Querier, reminderID int64, nextRun time.Time) (bool, error) {
 	res, err := db.Exec(
-		`INSERT INTO reminders (name, owner, rule, channel, message) VALUES (?, ?, ?, ?, ?)`,
+		`UPDATE reminders SET next_run_at = ?, modified_at = modified_at WHERE id = ? AND next_run_at IS NULL`,
+		nextRun.UTC(),
+		reminderID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("set reminder next run: execute query: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("set reminder next run: get affected rows: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// LockDueReminders locks the reminders due by now that are not locked by
+// other transactions, earliest first.
+func LockDueReminders(tx *sql.Tx, now time.Time, limit int) ([]models.Reminder, error) {
+	rows, err := tx.Query(
+		"SELECT "+reminderCols+" FROM reminders "+
+			"WHERE next_run_at IS NOT NULL AND next_run_at <= ? "+
+			"ORDER BY next_run_at, id LIMIT ? FOR UPDATE SKIP LOCKED",
+		now.UTC(),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("lock due reminders: execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var reminders []models.Reminder
+
+	for rows.Next() {
+		reminder, err := extractReminderFromRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("lock due reminders: scan row: %w", err)
+		}
+
+		reminders = append(reminders, *reminder)
+	}
+
+	return reminders, rows.Err()
+}
+
+// UpdateReminderLastRun persists the last of the runs fired and counts them
+// as occurrences of the reminder. The last run never moves back, runs may be
+// handled out of order.
+func UpdateReminderLastRun(db Querier, reminderID int64, lastRun time.Time, runs int) error {
+	_, err := db.Exec(
+		`UPDATE reminders SET last_run_at = GREATEST(COALESCE(last_run_at, ?), ?), occurrences = occurrences + ?, modified_at = modified_at WHERE id = ?`,
+		lastRun.UTC(),
+		lastRun.UTC(),
+		runs,
+		reminderID,
+	)
+	if err != nil {
+		return fmt.Errorf("update reminder last run: execute query: %w", err)
+	}
+	return nil
+}
+
+// CreateReminder stores the reminder, its calendar is resolved to calendarID
+// by the caller.
+func CreateReminder(db *sql.DB, req dtos.ReminderDTO, calendarID sql.NullInt64) (int64, error) {
+	res, err := db.Exec(
+		`INSERT INTO reminders (name, owner, rule, channel, message, catch_up, overlap, time_zone, schedule_type, calendar_id, holiday_policy, starts_at, ends_at, max_occurrences, notify_finish) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		req.Name,
 		req.Owner,
 		req.Rule,
 		req.Channel,
 		req.Message,
+		req.CatchUp,
+		req.Overlap,
+		sql.NullString{String: req.TimeZone, Valid: req.TimeZone != ""},
+		req.ScheduleType,
+		calendarID,
+		req.HolidayPolicy,
+		nullTime(req.StartsAt),
+		nullTime(req.EndsAt),
+		sql.NullInt64{Int64: req.MaxOccurrences, Valid: req.MaxOccurrences > 0},
+		req.NotifyFinish,
 	)
 	if err != nil {
 		return 0, err
@@ -186,7 +483,20 @@ func CreateReminder(db *sql.DB, req dtos.ReminderDTO) (int64, error) {
 	return lastInsertID, nil
 }
 
-func DeleteReminder(db *sql.DB, reminderID int64) error {
+// ArchiveReminder keeps the reminder that has no runs left but takes it off
+// the schedule.
+func ArchiveReminder(db Querier, reminderID int64, archivedAt time.Time) error {
+	if _, err := db.Exec(
+		`UPDATE reminders SET archived_at = ?, next_run_at = NULL, modified_at = modified_at WHERE id = ?`,
+		archivedAt.UTC(),
+		reminderID,
+	); err != nil {
+		return fmt.Errorf("archive reminder: execute query: %w", err)
+	}
+	return nil
+}
+
+func DeleteReminder(db Querier, reminderID int64) error {
 	res, err := db.Exec(`DELETE FROM reminders WHERE id = ?`, reminderID)
 	if err != nil {
 		return err
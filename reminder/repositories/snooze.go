@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+)
+
+func CreateSnooze(db Querier, reminderID int64, runAt time.Time) error {
+	if _, err := db.Exec(
+		`INSERT INTO reminder_snoozes (reminder_id, run_at) VALUES (?, ?)`,
+		reminderID,
+		runAt.UTC(),
+	); err != nil {
+		return fmt.Errorf("create snooze: execute query: %w", err)
+	}
+	return nil
+}
+
+// LockDueSnoozes locks the snoozes due by now skipping the ones locked by
+// other instances.
+func LockDueSnoozes(tx *sql.Tx, now time.Time, limit int) ([]models.Snooze, error) {
+	rows, err := tx.Query(
+		"SELECT id, reminder_id, run_at FROM reminder_snoozes "+
+			"WHERE run_at <= ? ORDER BY run_at, id LIMIT ? FOR UPDATE SKIP LOCKED",
+		now.UTC(),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("lock due snoozes: execute query: %w", err)
+	}
+	defer rows.Close()
+
+	var snoozes []models.Snooze
+	for rows.Next() {
+		var snooze models.Snooze
+		var runAtString string
+		if err := rows.Scan(&snooze.ID, &snooze.ReminderID, &runAtString); err != nil {
+			return nil, fmt.Errorf("lock due snoozes: scan row: %w", err)
+		}
+		if snooze.RunAt, err = time.Parse(timestampLayout, runAtString); err != nil {
+			return nil, fmt.Errorf("lock due snoozes: parse run time: %w", err)
+		}
+		snoozes = append(snoozes, snooze)
+	}
+	return snoozes, rows.Err()
+}
+
+func DeleteSnooze(db Querier, snoozeID int64) error {
+	if _, err := db.Exec(`DELETE FROM reminder_snoozes WHERE id = ?`, snoozeID); err != nil {
+		return fmt.Errorf("delete snooze: execute query: %w", err)
+	}
+	return nil
+}
+
+func DeleteSnoozesOfReminders(db Querier, reminderIDs ...int64) error {
+	if len(reminderIDs) == 0 {
+		return nil
+	}
+
+	in, args := placeholders(reminderIDs)
+	if _, err := db.Exec(
+		"DELETE FROM reminder_snoozes WHERE reminder_id IN ("+in+")",
+		args...,
+	); err != nil {
+		return fmt.Errorf("delete snoozes of reminders: execute query: %w", err)
+	}
+	return nil
+}
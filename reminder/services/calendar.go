@@ -0,0 +1,202 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/ics"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
+)
+
+// FindCalendar looks the calendar up among the calendars of the channel and
+// the global ones.
+func FindCalendar(app *app.Application, name string, channel string) (*models.Calendar, error) {
+	calendar, err := repositories.FindCalendar(app.Db, name, channel)
+	if err != nil {
+		return nil, fmt.Errorf("calendar '%s': %w", name, err)
+	}
+	return calendar, nil
+}
+
+// AddCalendarDates excludes the dates by the calendar, creating the calendar
+// of the channel, or a global one, if there is no calendar with the name yet.
+// The reminders using the calendar are rescheduled.
+func AddCalendarDates(
+	app *app.Application,
+	name string,
+	channel string,
+	global bool,
+	dates []models.CalendarDate,
+) (*models.Calendar, error) {
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
+	calendar, err := findOrCreateCalendar(app, name, channel, global)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repositories.AddCalendarDates(app.Db, calendar.ID, dates); err != nil {
+		return nil, err
+	}
+	app.RemindManager.RescheduleCalendar(calendar.ID)
+	return calendar, nil
+}
+
+// SetCalendarWeekend sets the days of the week the runs shifted by the
+// calendar are not moved to, like "fri,sat" or "none". The calendar is
+// created as by AddCalendarDates and its reminders are rescheduled.
+func SetCalendarWeekend(
+	app *app.Application,
+	name string,
+	channel string,
+	global bool,
+	weekend string,
+) (*models.Calendar, error) {
+	weekend, err := models.ParseWeekend(weekend)
+	if err != nil {
+		return nil, err
+	}
+
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
+	calendar, err := findOrCreateCalendar(app, name, channel, global)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := repositories.UpdateCalendarWeekend(app.Db, calendar.ID, weekend); err != nil {
+		return nil, err
+	}
+	calendar.Weekend = weekend
+	app.RemindManager.RescheduleCalendar(calendar.ID)
+	return calendar, nil
+}
+
+// findOrCreateCalendar returns the calendar usable from the channel, creating
+// the calendar of the channel, or a global one, if there is none.
+func findOrCreateCalendar(
+	app *app.Application,
+	name string,
+	channel string,
+	global bool,
+) (*models.Calendar, error) {
+	calendar, err := repositories.FindCalendar(app.Db, name, channel)
+	if errors.Is(err, sql.ErrNoRows) {
+		calendar = &models.Calendar{Name: name, Weekend: models.DefaultWeekend}
+		if !global {
+			calendar.Channel = sql.NullString{String: channel, Valid: true}
+		}
+		calendar.ID, err = repositories.CreateCalendar(app.Db, name, calendar.Channel, calendar.Weekend)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("calendar '%s': %w", name, err)
+	}
+	return calendar, nil
+}
+
+// ErrInvalidCalendar is returned when an imported .ics file cannot be read.
+var ErrInvalidCalendar = errors.New("invalid calendar")
+
+// ImportCalendar excludes the days of the events of the .ics file by the
+// calendar, see AddCalendarDates. It returns the amount of days imported.
+func ImportCalendar(
+	app *app.Application,
+	name string,
+	channel string,
+	global bool,
+	r io.Reader,
+) (int, error) {
+	dates, err := ics.Parse(r)
+	if err != nil {
+		return 0, fmt.Errorf("import calendar: %w: %w", ErrInvalidCalendar, err)
+	}
+	if _, err := AddCalendarDates(app, name, channel, global, dates); err != nil {
+		return 0, fmt.Errorf("import calendar: %w", err)
+	}
+	return len(dates), nil
+}
+
+// RemoveCalendarDates stops excluding the dates by the calendar, or deletes
+// the calendar if no dates are given. It returns the amount of dates removed.
+func RemoveCalendarDates(
+	app *app.Application,
+	name string,
+	channel string,
+	dates []time.Time,
+) (int64, error) {
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
+	calendar, err := FindCalendar(app, name, channel)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(dates) > 0 {
+		removed, err := repositories.DeleteCalendarDates(app.Db, calendar.ID, dates)
+		if err != nil {
+			return 0, err
+		}
+		app.RemindManager.RescheduleCalendar(calendar.ID)
+		return removed, nil
+	}
+
+	reminders, err := repositories.GetRemindersByCalendar(app.Db, calendar.ID)
+	if err != nil {
+		return 0, fmt.Errorf("get reminders of calendar: %w", err)
+	}
+	if err := repositories.DeleteCalendar(app.Db, calendar.ID); err != nil {
+		return 0, err
+	}
+
+	detached := make([]models.Reminder, 0, len(reminders))
+	for _, reminder := range reminders {
+		r, err := repositories.GetReminder(app.Db, reminder.ID)
+		if err != nil {
+			return 0, fmt.Errorf("get detached reminder: %w", err)
+		}
+		detached = append(detached, *r)
+	}
+	app.RemindManager.RescheduleReminders(detached...)
+	return 0, nil
+}
+
+func GetCalendars(app *app.Application, channel string) ([]models.Calendar, error) {
+	return repositories.GetCalendars(app.Db, channel)
+}
+
+func GetCalendarDates(app *app.Application, calendarID int64) ([]models.CalendarDate, error) {
+	return repositories.GetCalendarDates(app.Db, calendarID)
+}
+
+// resolveCalendar returns the id of the calendar with the name usable from
+// the channel, or no id if the name is empty.
+func resolveCalendar(app *app.Application, name string, channel string) (sql.NullInt64, error) {
+	if name == "" {
+		return sql.NullInt64{}, nil
+	}
+	calendar, err := FindCalendar(app, name, channel)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	return sql.NullInt64{Int64: calendar.ID, Valid: true}, nil
+}
+
+func validateHolidayPolicy(policy string) error {
+	if models.IsValidHolidayPolicy(policy) {
+		return nil
+	}
+	return fmt.Errorf(
+		"invalid holiday policy '%s': expected one of %s, %s",
+		policy,
+		models.HolidaySkip,
+		models.HolidayShift,
+	)
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"time"
+
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
@@ -21,3 +23,28 @@ func InsertChannel(app *app.Application, channel models.Channel) error {
 func DeleteChannel(app *app.Application, name string) error {
 	return repositories.DeleteChannel(app.Db, name)
 }
+
+// GetReminderLocation returns the time zone of the reminder falling back to
+// the one of its channel.
+func GetReminderLocation(app *app.Application, reminder *models.Reminder) *time.Location {
+	if reminder.TimeZone.Valid {
+		if loc, err := time.LoadLocation(reminder.TimeZone.String); err == nil {
+			return loc
+		}
+	}
+	return GetChannelLocation(app, reminder.Channel)
+}
+
+// GetChannelLocation returns the time zone of the channel falling back to the
+// default one.
+func GetChannelLocation(app *app.Application, name string) *time.Location {
+	channel, err := repositories.GetChannel(app.Db, name)
+	if err != nil {
+		return app.DefaultLocation
+	}
+	loc, err := time.LoadLocation(channel.TimeZone)
+	if err != nil {
+		return app.DefaultLocation
+	}
+	return loc
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dtos"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
+)
+
+// defaultHistoryLimit is the amount of deliveries shown when no limit is
+// requested.
+const defaultHistoryLimit = 10
+
+func ReportDelivery(app *app.Application, delivery dtos.DeliveryDTO) (int64, error) {
+	if delivery.ReminderID == 0 || delivery.OccurrenceID == "" {
+		return 0, fmt.Errorf("report delivery: reminder id and occurrence id are required")
+	}
+	if delivery.Attempt <= 0 {
+		delivery.Attempt = 1
+	}
+	return repositories.CreateDelivery(app.Db, delivery)
+}
+
+func GetDeliveries(
+	app *app.Application,
+	reminderID int64,
+	limit int,
+) ([]models.Delivery, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	return repositories.GetDeliveriesByReminder(app.Db, reminderID, limit)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dispatch"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dtos"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
+	"github.com/rs/zerolog/log"
+)
+
+// embeddedClaimant is the name the embedded dispatcher claims reminds with.
+// External pollers may run alongside, leases keep them from sending the same
+// reminds.
+const embeddedClaimant = "embedded"
+
+// embeddedHeartbeat is how often the embedded dispatcher claims reminds
+// without being notified, picking up the ones whose lease expired.
+const embeddedHeartbeat = 15 * time.Second
+
+// embeddedTracker records the deliveries of the embedded dispatcher directly
+// in the application.
+type embeddedTracker struct {
+	app *app.Application
+}
+
+func (t embeddedTracker) ReportDelivery(_ context.Context, d dispatch.Delivery) error {
+	_, err := ReportDelivery(t.app, dtos.DeliveryDTO{
+		ReminderID:   d.ReminderID,
+		OccurrenceID: d.OccurrenceID,
+		ScheduledAt:  d.ScheduledAt,
+		Attempt:      d.Attempt,
+		StatusCode:   d.StatusCode,
+		Error:        d.Error,
+		DeliveredAt:  d.DeliveredAt,
+	})
+	return err
+}
+
+func (t embeddedTracker) Complete(_ context.Context, remind dispatch.Remind) error {
+	CompleteReminds(t.app, []string{remind.OccurrenceID})
+	return nil
+}
+
+func (t embeddedTracker) Fail(
+	_ context.Context,
+	remind dispatch.Remind,
+	reason error,
+) error {
+	unknown := FailReminds(t.app, []dtos.FailedRemindDTO{
+		{OccurrenceID: remind.OccurrenceID, Error: reason.Error()},
+	})
+	if len(unknown) > 0 {
+		return fmt.Errorf("fail remind: remind %s is not pending", remind.OccurrenceID)
+	}
+	return nil
+}
+
+func remindToDispatch(remind models.Remind) dispatch.Remind {
+	return dispatch.Remind{
+		OccurrenceID:   remind.OccurrenceID,
+		IdempotencyKey: remind.IdempotencyKey,
+		ScheduledAt:    remind.ScheduledAt,
+		ReminderID:     remind.ReminderId,
+		Name:           remind.Name,
+		Rule:           remind.Rule,
+		Channel:        remind.Channel,
+		Message:        remind.Message,
+		Webhook:        remind.Webhook,
+	}
+}
+
+// RunEmbeddedDispatcher posts the triggered reminds to Mattermost from the
+// reminder service itself until the context is canceled, then waits for the
+// started deliveries.
+func RunEmbeddedDispatcher(
+	c context.Context,
+	app *app.Application,
+	cfg dispatch.Config,
+) error {
+	dispatcher, err := dispatch.New(cfg, embeddedTracker{app: app})
+	if err != nil {
+		return fmt.Errorf("run embedded dispatcher: %w", err)
+	}
+	defer dispatcher.Close()
+
+	updates, cancel := SubscribeReminds(app)
+	defer cancel()
+
+	heartbeat := time.NewTicker(embeddedHeartbeat)
+	defer heartbeat.Stop()
+
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+
+	log.Info().Str("mattermost url", cfg.MattermostURL).Msg("Embedded dispatcher started")
+	for {
+		if reminds := ClaimReminds(app, embeddedClaimant, 0); len(reminds) > 0 {
+			converted := make([]dispatch.Remind, len(reminds))
+			for i, remind := range reminds {
+				converted[i] = remindToDispatch(remind)
+			}
+			dispatcher.Dispatch(c, wg, converted)
+		}
+
+		select {
+		case <-updates:
+		case <-heartbeat.C:
+		case <-c.Done():
+			return nil
+		}
+	}
+}
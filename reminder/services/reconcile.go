@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/rman"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
+	"github.com/rs/zerolog/log"
+)
+
+// ReconcileReminders makes the schedule match the reminders stored in the
+// database, picking up the changes made bypassing the service, e.g. by an
+// admin or by another instance.
+func ReconcileReminders(app *app.Application) (rman.ReconcileResult, error) {
+	app.ScheduleMu.Lock()
+	defer app.ScheduleMu.Unlock()
+
+	reminders, err := repositories.GetReminders(app.Db)
+	if err != nil {
+		return rman.ReconcileResult{}, fmt.Errorf("reconcile reminders: %w", err)
+	}
+	return app.RemindManager.Reconcile(reminders...), nil
+}
+
+// RunReconciler reconciles the schedule every interval until the context is
+// canceled.
+func RunReconciler(c context.Context, app *app.Application, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := ReconcileReminders(app); err != nil {
+				log.Err(err).Msg("Cannot reconcile reminders")
+			}
+		case <-c.Done():
+			return
+		}
+	}
+}
@@ -1,36 +1,363 @@
 package services
 
 import (
+	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dtos"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/natural"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/rman"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/repositories"
-	"github.com/gorhill/cronexpr"
 )
 
+// CreateReminder stores the reminder and schedules it. It returns the id and
+// the next run of the reminder, zero if the rule has no future runs, then the
+// reminder is archived right away.
 func CreateReminder(
 	app *app.Application,
 	reminderDTO dtos.ReminderDTO,
-) (int64, error) {
-	if _, err := cronexpr.Parse(reminderDTO.Rule); err != nil {
-		return 0, fmt.Errorf("parse cron expr: %w", err)
+) (int64, time.Time, error) {
+	if reminderDTO.ScheduleType == "" {
+		reminderDTO.ScheduleType = models.ScheduleCron
+		if schedule.IsRRule(reminderDTO.Rule) {
+			reminderDTO.ScheduleType = models.ScheduleRRule
+		}
+	}
+	if !models.IsValidScheduleType(reminderDTO.ScheduleType) {
+		return 0, time.Time{}, fmt.Errorf(
+			"invalid schedule type '%s': expected one of %s, %s, %s, %s",
+			reminderDTO.ScheduleType,
+			models.ScheduleCron,
+			models.ScheduleOnce,
+			models.ScheduleInterval,
+			models.ScheduleRRule,
+		)
+	}
+
+	var loc *time.Location
+	if reminderDTO.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(reminderDTO.TimeZone); err != nil {
+			return 0, time.Time{}, fmt.Errorf("parse timezone: %w", err)
+		}
+	} else {
+		loc = GetChannelLocation(app, reminderDTO.Channel)
+	}
+
+	rule, err := validateRule(reminderDTO.ScheduleType, reminderDTO.Rule, loc)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	reminderDTO.Rule = rule
+
+	// Missed runs are not caught up unless asked, as it was before the policy
+	// was added, but a missed one-shot reminder would be lost for good.
+	if reminderDTO.CatchUp == "" {
+		reminderDTO.CatchUp = models.CatchUpSkip
+		if reminderDTO.ScheduleType == models.ScheduleOnce {
+			reminderDTO.CatchUp = models.CatchUpLatest
+		}
+	}
+	if !models.IsValidCatchUp(reminderDTO.CatchUp) {
+		return 0, time.Time{}, fmt.Errorf(
+			"invalid catch-up policy '%s': expected one of %s, %s, %s",
+			reminderDTO.CatchUp,
+			models.CatchUpAll,
+			models.CatchUpLatest,
+			models.CatchUpSkip,
+		)
+	}
+
+	if reminderDTO.Overlap == "" {
+		reminderDTO.Overlap = models.OverlapCoalesce
+	}
+	if !models.IsValidOverlap(reminderDTO.Overlap) {
+		return 0, time.Time{}, fmt.Errorf(
+			"invalid overlap policy '%s': expected one of %s, %s, %s",
+			reminderDTO.Overlap,
+			models.OverlapQueue,
+			models.OverlapCoalesce,
+			models.OverlapDrop,
+		)
 	}
 
-	id, err := repositories.CreateReminder(app.Db, reminderDTO)
+	if reminderDTO.HolidayPolicy == "" {
+		reminderDTO.HolidayPolicy = models.HolidaySkip
+	}
+	if err := validateHolidayPolicy(reminderDTO.HolidayPolicy); err != nil {
+		return 0, time.Time{}, err
+	}
+	calendarID, err := resolveCalendar(app, reminderDTO.Calendar, reminderDTO.Channel)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	if reminderDTO.MaxOccurrences < 0 {
+		return 0, time.Time{}, fmt.Errorf(
+			"invalid max occurrences %d: must be positive",
+			reminderDTO.MaxOccurrences,
+		)
+	}
+	if reminderDTO.StartsAt != nil && reminderDTO.EndsAt != nil &&
+		!reminderDTO.EndsAt.After(*reminderDTO.StartsAt) {
+		return 0, time.Time{}, fmt.Errorf("invalid bounds: the end must be after the start")
+	}
+
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
+	id, err := repositories.CreateReminder(app.Db, reminderDTO, calendarID)
 	if err != nil {
-		return 0, fmt.Errorf("create reminder: %w", err)
+		return 0, time.Time{}, fmt.Errorf("create reminder: %w", err)
 	}
 
 	reminder, err := repositories.GetReminder(app.Db, id)
 	if err != nil {
-		return 0, fmt.Errorf("get created reminder: %w", err)
+		return 0, time.Time{}, fmt.Errorf("get created reminder: %w", err)
 	}
 
 	app.RemindManager.AddReminders(*reminder)
 
-	return id, nil
+	runs, err := rman.NextRuns(app.Db, *reminder, GetReminderLocation(app, reminder), time.Now(), 1)
+	if err != nil || len(runs) == 0 {
+		return id, time.Time{}, nil
+	}
+	return id, runs[0], nil
+}
+
+// EditReminder applies the patch to the reminder and reschedules it by its
+// new rule. The schedule type of the new rule is detected from the rule unless
+// the patch sets it. The next run is zero if the rule has no future runs.
+func EditReminder(
+	app *app.Application,
+	reminderID int64,
+	patch dtos.ReminderPatchDTO,
+) (*models.Reminder, time.Time, error) {
+	if patch.Name == nil && patch.Rule == nil && patch.Message == nil &&
+		patch.Calendar == nil && patch.HolidayPolicy == nil {
+		return nil, time.Time{}, fmt.Errorf("nothing to edit")
+	}
+
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
+	reminder, err := repositories.GetReminder(app.Db, reminderID)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("get reminder: %w", err)
+	}
+	if patch.Channel != "" {
+		req := dtos.MMRequest{ChannelName: patch.Channel}
+		if err := checkChannelAccess(reminder, req, "edited"); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	if patch.Rule != nil {
+		scheduleType := ""
+		if patch.ScheduleType != nil {
+			scheduleType = *patch.ScheduleType
+		}
+		loc := GetReminderLocation(app, reminder)
+		scheduleType, rule, err := resolveRule(scheduleType, *patch.Rule, loc)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		patch.ScheduleType = &scheduleType
+		patch.Rule = &rule
+	} else if patch.ScheduleType != nil {
+		return nil, time.Time{}, fmt.Errorf("schedule type is changed only together with the rule")
+	}
+
+	if patch.HolidayPolicy != nil {
+		if err := validateHolidayPolicy(*patch.HolidayPolicy); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+	var calendarID sql.NullInt64
+	if patch.Calendar != nil {
+		if calendarID, err = resolveCalendar(app, *patch.Calendar, reminder.Channel); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+
+	if err := repositories.UpdateReminder(app.Db, reminderID, patch, calendarID); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	reminder, err = repositories.GetReminder(app.Db, reminderID)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("get edited reminder: %w", err)
+	}
+
+	nextRuns := app.RemindManager.RescheduleReminders(*reminder)
+	return reminder, nextRuns[reminder.ID], nil
+}
+
+// PauseReminder skips the runs of the reminder until it is resumed, or until
+// the time if it is set. If channel is set, the reminder must belong to it.
+func PauseReminder(
+	app *app.Application,
+	reminderID int64,
+	until sql.NullTime,
+	channel string,
+) (*models.Reminder, error) {
+	if until.Valid && !until.Time.After(time.Now()) {
+		return nil, fmt.Errorf("pause until %s: time is in the past", until.Time.Format(time.DateTime))
+	}
+	until.Time = until.Time.UTC()
+
+	reminder, _, err := setReminderPause(app, reminderID, true, until, channel)
+	return reminder, err
+}
+
+// ResumeReminder reschedules the paused reminder and returns its next run,
+// the runs skipped during the pause are not caught up. If channel is set, the
+// reminder must belong to it.
+func ResumeReminder(
+	app *app.Application,
+	reminderID int64,
+	channel string,
+) (*models.Reminder, time.Time, error) {
+	return setReminderPause(app, reminderID, false, sql.NullTime{}, channel)
+}
+
+func setReminderPause(
+	app *app.Application,
+	reminderID int64,
+	paused bool,
+	until sql.NullTime,
+	channel string,
+) (*models.Reminder, time.Time, error) {
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
+	reminder, err := repositories.GetReminder(app.Db, reminderID)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("get reminder: %w", err)
+	}
+	if channel != "" {
+		action := "resumed"
+		if paused {
+			action = "paused"
+		}
+		req := dtos.MMRequest{ChannelName: channel}
+		if err := checkChannelAccess(reminder, req, action); err != nil {
+			return nil, time.Time{}, err
+		}
+	}
+
+	if err := repositories.UpdateReminderPause(app.Db, reminderID, !paused, until); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	reminder, err = repositories.GetReminder(app.Db, reminderID)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("get reminder: %w", err)
+	}
+
+	nextRuns := app.RemindManager.RescheduleReminders(*reminder)
+	return reminder, nextRuns[reminder.ID], nil
+}
+
+// SnoozeReminder sends an extra occurrence of the reminder at runAt without
+// changing its schedule. If channel is set, the reminder must belong to it.
+func SnoozeReminder(
+	app *app.Application,
+	reminderID int64,
+	runAt time.Time,
+	channel string,
+) (*models.Reminder, error) {
+	if !runAt.After(time.Now()) {
+		return nil, fmt.Errorf("snooze until %s: time is in the past", runAt.Format(time.DateTime))
+	}
+
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
+	reminder, err := repositories.GetReminder(app.Db, reminderID)
+	if err != nil {
+		return nil, fmt.Errorf("get reminder: %w", err)
+	}
+	if channel != "" {
+		req := dtos.MMRequest{ChannelName: channel}
+		if err := checkChannelAccess(reminder, req, "snoozed"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := app.RemindManager.SnoozeReminder(*reminder, runAt); err != nil {
+		return nil, fmt.Errorf("snooze reminder: %w", err)
+	}
+	return reminder, nil
+}
+
+// resolveRule detects the schedule type of the rule if it is empty, the same
+// way as `/reminder add` does, or a date is read as a one-shot rule, and
+// returns the type with the rule in the stored form.
+func resolveRule(scheduleType string, rule string, loc *time.Location) (string, string, error) {
+	if scheduleType == "" {
+		detected, _, err := parseRuleOrPhrase(rule, loc, time.Now())
+		if err != nil {
+			if _, dateErr := parseDateTime(rule, loc); dateErr != nil {
+				return "", "", err
+			}
+			detected = natural.Schedule{Type: models.ScheduleOnce, Rule: rule}
+		}
+		scheduleType, rule = detected.Type, detected.Rule
+	}
+	if !models.IsValidScheduleType(scheduleType) {
+		return "", "", fmt.Errorf(
+			"invalid schedule type '%s': expected one of %s, %s, %s, %s",
+			scheduleType,
+			models.ScheduleCron,
+			models.ScheduleOnce,
+			models.ScheduleInterval,
+			models.ScheduleRRule,
+		)
+	}
+
+	rule, err := validateRule(scheduleType, rule, loc)
+	if err != nil {
+		return "", "", err
+	}
+	return scheduleType, rule, nil
+}
+
+// validateRule checks the rule of the schedule type and returns it in the
+// stored form. One-shot rules are dates in loc that must be in the future,
+// recurrence rules without DTSTART start now in loc.
+func validateRule(scheduleType string, rule string, loc *time.Location) (string, error) {
+	switch scheduleType {
+	case models.ScheduleOnce:
+		at, err := parseDateTime(rule, loc)
+		if err != nil {
+			return "", err
+		}
+		if !at.After(time.Now()) {
+			return "", fmt.Errorf("time %s is in the past", at.Format(time.DateTime))
+		}
+		return at.Format(models.OnceLayout), nil
+	case models.ScheduleInterval:
+		interval, err := schedule.ParseInterval(rule)
+		if err != nil {
+			return "", err
+		}
+		return interval.String(), nil
+	case models.ScheduleRRule:
+		rrule, err := schedule.ParseRRule(rule, time.Now().In(loc))
+		if err != nil {
+			return "", err
+		}
+		return rrule.String(), nil
+	default:
+		if _, err := schedule.Parse(scheduleType, rule); err != nil {
+			return "", fmt.Errorf("parse cron expr: %w", err)
+		}
+		return rule, nil
+	}
 }
 
 func UpdateReminderOwner(
@@ -38,11 +365,17 @@ func UpdateReminderOwner(
 	reminderID int64,
 	userName string,
 ) error {
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
 	app.RemindManager.UpdateReminderOwner(reminderID, userName)
 	return repositories.UpdateReminderOwner(app.Db, reminderID, userName)
 }
 
 func DeleteReminder(app *app.Application, reminderID int64) error {
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
 	app.RemindManager.RemoveReminders(reminderID)
 	return repositories.DeleteReminder(app.Db, reminderID)
 }
@@ -2,14 +2,19 @@ package services
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dtos"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/natural"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/rman"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/internal/schedule"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
 )
 
@@ -24,30 +29,189 @@ type undeleted struct {
 	err error
 }
 
+// splitOptions separates `--key value` and `--key=value` options from the
+// positional arguments of a slash command. Options listed in flags take no
+// value and are stored with an empty one.
+func splitOptions(
+	tokens []string,
+	flags ...string,
+) ([]string, map[string]string, error) {
+	var args []string
+	opts := make(map[string]string)
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if !strings.HasPrefix(token, "--") || len(token) == 2 {
+			args = append(args, token)
+			continue
+		}
+
+		key, value, found := strings.Cut(token[2:], "=")
+		if !found && !slices.Contains(flags, key) {
+			if i+1 >= len(tokens) {
+				return nil, nil, fmt.Errorf("option '--%s' requires a value", key)
+			}
+			i++
+			value = tokens[i]
+		}
+		opts[key] = value
+	}
+	return args, opts, nil
+}
+
+func checkOptions(opts map[string]string, allowed ...string) error {
+	for key := range opts {
+		if !slices.Contains(allowed, key) {
+			return fmt.Errorf("unknown option '--%s'", key)
+		}
+	}
+	return nil
+}
+
 func MMReminderCreate(
 	app *app.Application,
 	req dtos.MMRequest,
 	tokens []string,
-) error {
-	if len(tokens) < 4 {
-		return wrongArgCntErr{}
+) (string, error) {
+	args, opts, err := splitOptions(tokens, "notify-finish")
+	if err != nil {
+		return "", err
+	}
+	if err := checkOptions(
+		opts,
+		"catch-up", "overlap", "tz", "calendar", "on-holiday",
+		"starts", "ends", "times", "notify-finish",
+	); err != nil {
+		return "", err
+	}
+	if len(args) < 4 {
+		return "", wrongArgCntErr{}
 	}
 
 	rem := dtos.ReminderDTO{
-		Name:    tokens[1],
-		Owner:   req.UserName,
-		Rule:    tokens[2],
-		Message: tokens[3],
-		Channel: req.ChannelName,
+		Name:          args[1],
+		Owner:         req.UserName,
+		Rule:          args[2],
+		Message:       args[3],
+		Channel:       req.ChannelName,
+		CatchUp:       opts["catch-up"],
+		Overlap:       opts["overlap"],
+		TimeZone:      opts["tz"],
+		Calendar:      opts["calendar"],
+		HolidayPolicy: opts["on-holiday"],
+	}
+	_, rem.NotifyFinish = opts["notify-finish"]
+	loc := GetChannelLocation(app, req.ChannelName)
+	if rem.TimeZone != "" {
+		if loc, err = time.LoadLocation(rem.TimeZone); err != nil {
+			return "", fmt.Errorf("parse timezone: %w", err)
+		}
+	}
+	if err := parseBounds(&rem, opts, loc); err != nil {
+		return "", err
 	}
-	_, err := CreateReminder(app, rem)
+
+	reply := "Reminder successfully created"
+	if kind := strings.ToLower(args[2]); kind == "at" || kind == "in" {
+		if len(args) < 5 {
+			return "", wrongArgCntErr{}
+		}
+		at, err := parseOnce(kind, args[3], loc, time.Now())
+		if err != nil {
+			return "", err
+		}
+		rem.ScheduleType = models.ScheduleOnce
+		rem.Rule = at.Format(models.OnceLayout)
+		rem.Message = args[4]
+	} else {
+		schedule, interpreted, err := parseRuleOrPhrase(rem.Rule, loc, time.Now())
+		if err != nil {
+			return "", err
+		}
+		rem.ScheduleType = schedule.Type
+		rem.Rule = schedule.Rule
+		if interpreted {
+			reply += fmt.Sprintf("\nInterpreted \"%s\" as %s", args[2], describeRule(models.Reminder{
+				Rule:         schedule.Rule,
+				ScheduleType: schedule.Type,
+			}))
+			if schedule.Note != "" {
+				reply += "\nNote: " + schedule.Note
+			}
+		}
+	}
+	_, nextRun, err := CreateReminder(app, rem)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if nextRun.IsZero() {
+		reply += "\nWarning: the rule has no future runs, the reminder is archived right away. " +
+			"It is shown by `/reminder list --all`"
 	}
 
+	return reply, nil
+}
+
+// parseBounds reads the `--starts`, `--ends` and `--times` options into the
+// reminder. A date without a time ends the reminder at the end of the day.
+func parseBounds(rem *dtos.ReminderDTO, opts map[string]string, loc *time.Location) error {
+	if value, ok := opts["starts"]; ok {
+		starts, err := parseDateTime(value, loc)
+		if err != nil {
+			return err
+		}
+		rem.StartsAt = &starts
+	}
+	if value, ok := opts["ends"]; ok {
+		ends, err := parseDateTime(value, loc)
+		if err != nil {
+			return err
+		}
+		if len(value) == len(time.DateOnly) {
+			ends = ends.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		rem.EndsAt = &ends
+	}
+	if value, ok := opts["times"]; ok {
+		times, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || times <= 0 {
+			return fmt.Errorf("invalid amount of times '%s': expected a positive number", value)
+		}
+		rem.MaxOccurrences = times
+	}
 	return nil
 }
 
+// parseRuleOrPhrase reads the rule as a cron rule, an interval like
+// "every 2w from 2026-11-04 10:00", a recurrence rule like
+// "RRULE:FREQ=MONTHLY;BYDAY=2TU" or else as a phrase like "every weekday at
+// 9:30", reporting whether it was interpreted as a phrase.
+func parseRuleOrPhrase(
+	rule string,
+	loc *time.Location,
+	now time.Time,
+) (natural.Schedule, bool, error) {
+	if schedule.IsRRule(rule) {
+		rrule, err := schedule.ParseRRule(rule, now.In(loc))
+		if err != nil {
+			return natural.Schedule{}, false, err
+		}
+		return natural.Schedule{Type: models.ScheduleRRule, Rule: rrule.String()}, false, nil
+	}
+	if _, err := schedule.Parse(models.ScheduleCron, rule); err == nil {
+		return natural.Schedule{Type: models.ScheduleCron, Rule: rule}, false, nil
+	}
+	if interval, err := schedule.ParseInterval(rule); err == nil {
+		return natural.Schedule{Type: models.ScheduleInterval, Rule: interval.String()}, false, nil
+	}
+	phrase, err := natural.Parse(rule, now.In(loc))
+	if err != nil {
+		return natural.Schedule{}, false, fmt.Errorf(
+			"'%s' is neither a cron rule, an interval nor a known phrase: %w", rule, err,
+		)
+	}
+	return phrase, true, nil
+}
+
 func rmLineBreaks(s string) string {
 	pos := strings.Index(s, "\n")
 	if pos != -1 {
@@ -57,34 +221,104 @@ func rmLineBreaks(s string) string {
 	}
 }
 
-func MMReminderList(app *app.Application, req dtos.MMRequest) (string, error) {
+// MMReminderList lists the reminders of the channel, the archived ones only
+// with `--all`.
+func MMReminderList(
+	app *app.Application,
+	req dtos.MMRequest,
+	tokens []string,
+) (string, error) {
+	_, opts, err := splitOptions(tokens, "all")
+	if err != nil {
+		return "", err
+	}
+	if err := checkOptions(opts, "all"); err != nil {
+		return "", err
+	}
+	_, all := opts["all"]
+
 	reminders, err := GetRemindersByChannel(app, req.ChannelName)
 	if err != nil {
 		return "", fmt.Errorf("get reminders by channel: %w", err)
 	}
+	archived := len(reminders)
+	if !all {
+		reminders = slices.DeleteFunc(reminders, func(reminder models.Reminder) bool {
+			return reminder.ArchivedAt.Valid
+		})
+	}
+	archived -= len(reminders)
 
 	if len(reminders) > 0 {
 		var sb strings.Builder
-		sb.WriteString("|Id|Name|Owner|Channel|Rule|Message|\n|-|-|-|-|-|-|\n")
+		now := time.Now()
+		channelLoc := GetChannelLocation(app, req.ChannelName)
+		preview := rman.NewPreview(app.Db)
+		sb.WriteString("|Id|Name|Owner|Channel|Rule|Message|State|Next|\n|-|-|-|-|-|-|-|-|\n")
 		for _, reminder := range reminders {
+			loc := channelLoc
+			if reminder.TimeZone.Valid {
+				loc = GetReminderLocation(app, &reminder)
+			}
+			next := "-"
+			runs, err := preview.NextRuns(reminder, loc, now, 1)
+			if err == nil && len(runs) > 0 {
+				next = runs[0].In(loc).Format(runLayout)
+			}
 			sb.WriteString(
 				fmt.Sprintf(
-					"|%d|%s|%s|%s|%s|%s|\n",
+					"|%d|%s|%s|%s|%s|%s|%s|%s|\n",
 					reminder.ID,
 					rmLineBreaks(reminder.Name),
 					reminder.Owner.String,
 					reminder.Channel,
-					reminder.Rule,
+					describeRule(reminder),
 					rmLineBreaks(reminder.Message),
+					reminderState(reminder, loc, now),
+					next,
 				),
 			)
 		}
 
+		if archived > 0 {
+			sb.WriteString(fmt.Sprintf("\n%d archived reminders are shown by `/reminder list --all`", archived))
+		}
 		return sb.String(), nil
 	}
+	if archived > 0 {
+		return fmt.Sprintf(
+			"There are no active reminders in this channel, %d archived ones are shown by `/reminder list --all`",
+			archived,
+		), nil
+	}
 	return "There are no reminders in this channel yet! Add a new one using `/reminder add ...`", nil
 }
 
+// ErrInvalidAccess is returned when a reminder is managed from a channel it
+// does not belong to.
+var ErrInvalidAccess = errors.New("invalid access")
+
+// checkChannelAccess allows managing a reminder only from the channel it
+// belongs to. action completes the phrase "reminder cannot be ...".
+func checkChannelAccess(
+	reminder *models.Reminder,
+	req dtos.MMRequest,
+	action string,
+) error {
+	if strings.EqualFold(reminder.Channel, req.ChannelName) {
+		return nil
+	}
+	return fmt.Errorf(
+		"%w: reminder %d belongs to channel "+
+			"'%s' and cannot be %s from channel '%s'",
+		ErrInvalidAccess,
+		reminder.ID,
+		reminder.Channel,
+		action,
+		req.ChannelName,
+	)
+}
+
 func deleteRemindersAndCollect(
 	app *app.Application,
 	req dtos.MMRequest,
@@ -108,19 +342,10 @@ func deleteRemindersAndCollect(
 			)
 			continue
 		}
-		if !strings.EqualFold(reminder.Channel, req.ChannelName) {
+		if err := checkChannelAccess(reminder, req, "deleted"); err != nil {
 			undels = append(
 				undels,
-				undeleted{
-					id: id,
-					err: fmt.Errorf(
-						"get reminder: invalid access: reminder %d belongs to channel "+
-							"'%s' and cannot be deleted from channel '%s'",
-						id,
-						reminder.Channel,
-						req.ChannelName,
-					),
-				},
+				undeleted{id: id, err: fmt.Errorf("get reminder: %w", err)},
 			)
 			continue
 		}
@@ -180,23 +405,521 @@ func MMReminderDelete(
 	return constructMessage(deleted, undels), nil
 }
 
+func MMReminderEdit(
+	app *app.Application,
+	req dtos.MMRequest,
+	tokens []string,
+) (string, error) {
+	args, opts, err := splitOptions(tokens)
+	if err != nil {
+		return "", err
+	}
+	if err := checkOptions(opts, "name", "rule", "message", "calendar", "on-holiday"); err != nil {
+		return "", err
+	}
+	if len(args) != 2 || len(opts) == 0 {
+		return "", wrongArgCntErr{}
+	}
+
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("edit: parse id: %w", err)
+	}
+
+	patch := dtos.ReminderPatchDTO{Channel: req.ChannelName}
+	if name, ok := opts["name"]; ok {
+		patch.Name = &name
+	}
+	if rule, ok := opts["rule"]; ok {
+		patch.Rule = &rule
+	}
+	if message, ok := opts["message"]; ok {
+		patch.Message = &message
+	}
+	if calendar, ok := opts["calendar"]; ok {
+		if strings.EqualFold(calendar, "none") {
+			calendar = ""
+		}
+		patch.Calendar = &calendar
+	}
+	if policy, ok := opts["on-holiday"]; ok {
+		patch.HolidayPolicy = &policy
+	}
+
+	reminder, nextRun, err := EditReminder(app, id, patch)
+	if err != nil {
+		return "", fmt.Errorf("edit: %w", err)
+	}
+	if nextRun.IsZero() {
+		return fmt.Sprintf("Reminder %d edited, it has no future runs", id), nil
+	}
+
+	return fmt.Sprintf(
+		"Reminder %d edited, next run at %s",
+		id,
+		nextRun.In(GetReminderLocation(app, reminder)).Format(time.DateTime),
+	), nil
+}
+
+// dateTimeLayouts are the formats of the dates accepted by slash commands.
+var dateTimeLayouts = []string{"2006-01-02 15:04", time.DateTime, time.DateOnly}
+
+func parseDateTime(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf(
+		"invalid date '%s': expected YYYY-MM-DD or YYYY-MM-DD HH:MM", s,
+	)
+}
+
+// splitUntil separates the ids of a command from the date following `until`.
+func splitUntil(tokens []string) (ids []string, until string) {
+	for i, token := range tokens {
+		if strings.EqualFold(token, "until") {
+			return tokens[:i], strings.Join(tokens[i+1:], " ")
+		}
+	}
+	return tokens, ""
+}
+
+func MMReminderPause(
+	app *app.Application,
+	req dtos.MMRequest,
+	tokens []string,
+) (string, error) {
+	ids, untilString := splitUntil(tokens[1:])
+	if len(ids) == 0 || len(ids) != len(tokens)-1 && untilString == "" {
+		return "", wrongArgCntErr{}
+	}
+	// The date is read once as a wall clock time, it is placed in the time
+	// zone of every reminder below.
+	var untilWall time.Time
+	if untilString != "" {
+		var err error
+		if untilWall, err = parseDateTime(untilString, time.UTC); err != nil {
+			return "", fmt.Errorf("pause: %w", err)
+		}
+	}
+
+	var sb strings.Builder
+	for _, idString := range ids {
+		id, err := strconv.ParseInt(idString, 10, 64)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("Error pausing reminder %s: parse id: %s\n", idString, err))
+			continue
+		}
+
+		reminder, err := GetReminder(app, id)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("Error pausing reminder %d: get reminder: %s\n", id, err))
+			continue
+		}
+		if err := checkChannelAccess(reminder, req, "paused"); err != nil {
+			sb.WriteString(fmt.Sprintf("Error pausing reminder %d: %s\n", id, err))
+			continue
+		}
+
+		loc := GetReminderLocation(app, reminder)
+		var until sql.NullTime
+		if !untilWall.IsZero() {
+			until = sql.NullTime{
+				Time: time.Date(
+					untilWall.Year(), untilWall.Month(), untilWall.Day(),
+					untilWall.Hour(), untilWall.Minute(), 0, 0,
+					loc,
+				),
+				Valid: true,
+			}
+		}
+
+		if _, err := PauseReminder(app, id, until, ""); err != nil {
+			sb.WriteString(fmt.Sprintf("Error pausing reminder %d: %s\n", id, err))
+			continue
+		}
+		if until.Valid {
+			sb.WriteString(fmt.Sprintf(
+				"Reminder %d paused until %s\n",
+				id,
+				until.Time.In(loc).Format(time.DateTime),
+			))
+		} else {
+			sb.WriteString(fmt.Sprintf("Reminder %d paused\n", id))
+		}
+	}
+	return sb.String(), nil
+}
+
+func MMReminderResume(
+	app *app.Application,
+	req dtos.MMRequest,
+	tokens []string,
+) (string, error) {
+	if len(tokens) < 2 {
+		return "", wrongArgCntErr{}
+	}
+
+	var sb strings.Builder
+	for _, idString := range tokens[1:] {
+		id, err := strconv.ParseInt(idString, 10, 64)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("Error resuming reminder %s: parse id: %s\n", idString, err))
+			continue
+		}
+
+		reminder, nextRun, err := ResumeReminder(app, id, req.ChannelName)
+		switch {
+		case err != nil:
+			sb.WriteString(fmt.Sprintf("Error resuming reminder %d: %s\n", id, err))
+		case nextRun.IsZero():
+			sb.WriteString(fmt.Sprintf("Reminder %d resumed, it has no future runs\n", id))
+		default:
+			sb.WriteString(fmt.Sprintf(
+				"Reminder %d resumed, next run at %s\n",
+				id,
+				nextRun.In(GetReminderLocation(app, reminder)).Format(time.DateTime),
+			))
+		}
+	}
+	return sb.String(), nil
+}
+
+// displayRule shows the rule of the reminder along with its schedule type.
+func displayRule(reminder models.Reminder) string {
+	if reminder.ScheduleType == models.ScheduleOnce {
+		return "at " + reminder.Rule
+	}
+	return reminder.Rule
+}
+
+// describeRule explains the rule of the reminder in words next to the rule
+// itself.
+func describeRule(reminder models.Reminder) string {
+	if reminder.ScheduleType != models.ScheduleOnce {
+		if description, err := natural.Describe(reminder.Rule); err == nil {
+			return fmt.Sprintf("%s (`%s`)", description, reminder.Rule)
+		}
+	}
+	return displayRule(reminder)
+}
+
+// reminderState describes whether the reminder is active, paused or archived
+// at now, along with the bounds of its runs shown in loc.
+func reminderState(reminder models.Reminder, loc *time.Location, now time.Time) string {
+	var state string
+	switch {
+	case reminder.ArchivedAt.Valid:
+		return "archived " + reminder.ArchivedAt.Time.In(loc).Format("2006-01-02 15:04")
+	case !reminder.PausedAt(now):
+		state = "active"
+	case reminder.PausedUntil.Valid:
+		state = fmt.Sprintf(
+			"paused until %s",
+			reminder.PausedUntil.Time.In(loc).Format("2006-01-02 15:04"),
+		)
+	default:
+		state = "paused"
+	}
+
+	if reminder.StartsAt.Valid && now.Before(reminder.StartsAt.Time) {
+		state += ", starts " + reminder.StartsAt.Time.In(loc).Format("2006-01-02 15:04")
+	}
+	if reminder.EndsAt.Valid {
+		state += ", ends " + reminder.EndsAt.Time.In(loc).Format("2006-01-02 15:04")
+	}
+	if reminder.MaxOccurrences.Valid {
+		state += fmt.Sprintf(", ran %d of %d times", reminder.Occurrences, reminder.MaxOccurrences.Int64)
+	}
+	return state
+}
+
+// parseSnoozeTime parses either a duration from now or a time of parseAt.
+func parseSnoozeTime(s string, loc *time.Location, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return time.Time{}, fmt.Errorf("invalid duration '%s': must be positive", s)
+		}
+		return now.Add(d), nil
+	}
+	t, err := parseAt(s, loc, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"invalid time '%s': expected a duration (15m, 2h30m), HH:MM or a date", s,
+		)
+	}
+	return t, nil
+}
+
+// parseOnce parses the time of a one-shot reminder, either `at` a time of
+// parseAt or `in` a duration from now.
+func parseOnce(kind string, s string, loc *time.Location, now time.Time) (time.Time, error) {
+	if kind == "at" {
+		return parseAt(s, loc, now)
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return time.Time{}, fmt.Errorf("invalid duration '%s': expected a positive one like 2h30m", s)
+	}
+	return now.Add(d).In(loc), nil
+}
+
+// parseAt parses a date in loc. A bare time of day means its next
+// occurrence.
+func parseAt(s string, loc *time.Location, now time.Time) (time.Time, error) {
+	if clock, err := time.Parse("15:04", s); err == nil {
+		now = now.In(loc)
+		t := time.Date(
+			now.Year(), now.Month(), now.Day(),
+			clock.Hour(), clock.Minute(), 0, 0,
+			loc,
+		)
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+
+	t, err := parseDateTime(s, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(
+			"invalid time '%s': expected HH:MM, YYYY-MM-DD or YYYY-MM-DD HH:MM", s,
+		)
+	}
+	return t, nil
+}
+
+func MMReminderSnooze(
+	app *app.Application,
+	req dtos.MMRequest,
+	tokens []string,
+) (string, error) {
+	if len(tokens) < 3 {
+		return "", wrongArgCntErr{}
+	}
+	args := tokens[2:]
+	if strings.EqualFold(args[0], "until") || strings.EqualFold(args[0], "at") {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return "", wrongArgCntErr{}
+	}
+
+	id, err := strconv.ParseInt(tokens[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("snooze: parse id: %w", err)
+	}
+	reminder, err := GetReminder(app, id)
+	if err != nil {
+		return "", fmt.Errorf("snooze: get reminder: %w", err)
+	}
+	if err := checkChannelAccess(reminder, req, "snoozed"); err != nil {
+		return "", fmt.Errorf("snooze: %w", err)
+	}
+
+	loc := GetReminderLocation(app, reminder)
+	runAt, err := parseSnoozeTime(strings.Join(args, " "), loc, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("snooze: %w", err)
+	}
+	if _, err := SnoozeReminder(app, id, runAt, req.ChannelName); err != nil {
+		return "", fmt.Errorf("snooze: %w", err)
+	}
+
+	return fmt.Sprintf(
+		"Reminder %d snoozed, it will be sent once more at %s",
+		id,
+		runAt.In(loc).Format(time.DateTime),
+	), nil
+}
+
+// runLayout formats the upcoming runs of reminders.
+const runLayout = "Mon 2006-01-02 15:04"
+
+const (
+	// defaultPreviewCount is the amount of runs previewed when no count is
+	// given.
+	defaultPreviewCount = 5
+	maxPreviewCount     = 50
+)
+
+func MMReminderNext(
+	app *app.Application,
+	req dtos.MMRequest,
+	tokens []string,
+) (string, error) {
+	if len(tokens) < 2 || len(tokens) > 3 {
+		return "", wrongArgCntErr{}
+	}
+
+	count := defaultPreviewCount
+	if len(tokens) == 3 {
+		n, err := strconv.Atoi(tokens[2])
+		if err != nil || n <= 0 || n > maxPreviewCount {
+			return "", fmt.Errorf("next: count must be from 1 to %d, got '%s'", maxPreviewCount, tokens[2])
+		}
+		count = n
+	}
+
+	now := time.Now()
+	var reminder models.Reminder
+	var loc *time.Location
+	var subject string
+	if id, err := strconv.ParseInt(tokens[1], 10, 64); err == nil {
+		r, err := GetReminder(app, id)
+		if err != nil {
+			return "", fmt.Errorf("next: get reminder: %w", err)
+		}
+		if err := checkChannelAccess(r, req, "viewed"); err != nil {
+			return "", fmt.Errorf("next: %w", err)
+		}
+		reminder, loc = *r, GetReminderLocation(app, r)
+		subject = fmt.Sprintf("reminder %d, %s", id, describeRule(reminder))
+	} else {
+		loc = GetChannelLocation(app, req.ChannelName)
+		schedule, _, err := parseRuleOrPhrase(tokens[1], loc, now)
+		if err != nil {
+			return "", fmt.Errorf("next: %w", err)
+		}
+		reminder = models.Reminder{Rule: schedule.Rule, ScheduleType: schedule.Type}
+		subject = describeRule(reminder)
+	}
+
+	runs, err := rman.NextRuns(app.Db, reminder, loc, now, count)
+	if err != nil {
+		return "", fmt.Errorf("next: %w", err)
+	}
+	if len(runs) == 0 {
+		return fmt.Sprintf("There are no upcoming runs of %s", subject), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Next runs of %s in %s:\n", subject, loc))
+	for _, run := range runs {
+		sb.WriteString(fmt.Sprintf("- %s\n", run.In(loc).Format(runLayout)))
+	}
+	return sb.String(), nil
+}
+
+func MMReminderHistory(
+	app *app.Application,
+	req dtos.MMRequest,
+	tokens []string,
+) (string, error) {
+	if len(tokens) < 2 || len(tokens) > 3 {
+		return "", wrongArgCntErr{}
+	}
+
+	id, err := strconv.ParseInt(tokens[1], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("history: parse id: %w", err)
+	}
+	limit := defaultHistoryLimit
+	if len(tokens) == 3 {
+		if limit, err = strconv.Atoi(tokens[2]); err != nil || limit <= 0 {
+			return "", fmt.Errorf("history: invalid count '%s'", tokens[2])
+		}
+	}
+
+	reminder, err := GetReminder(app, id)
+	if err != nil {
+		return "", fmt.Errorf("history: get reminder: %w", err)
+	}
+	if err := checkChannelAccess(reminder, req, "viewed"); err != nil {
+		return "", fmt.Errorf("history: %w", err)
+	}
+
+	deliveries, err := GetDeliveries(app, id, limit)
+	if err != nil {
+		return "", fmt.Errorf("history: %w", err)
+	}
+	if len(deliveries) == 0 {
+		return fmt.Sprintf("Reminder %d has not been delivered yet", id), nil
+	}
+
+	loc := GetReminderLocation(app, reminder)
+	formatTime := func(t sql.NullTime) string {
+		if !t.Valid {
+			return "-"
+		}
+		return t.Time.In(loc).Format(time.DateTime)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("|Scheduled|Attempt|Status|Error|Delivered|\n|-|-|-|-|-|\n")
+	for _, delivery := range deliveries {
+		status := "-"
+		if delivery.StatusCode.Valid {
+			status = strconv.FormatInt(delivery.StatusCode.Int64, 10)
+		}
+		sb.WriteString(
+			fmt.Sprintf(
+				"|%s|%d|%s|%s|%s|\n",
+				formatTime(delivery.ScheduledAt),
+				delivery.Attempt,
+				status,
+				rmLineBreaks(delivery.Error.String),
+				formatTime(delivery.DeliveredAt),
+			),
+		)
+	}
+	return sb.String(), nil
+}
+
+// MMReminderTimeZoneSet changes the time zone of the channel and reschedules
+// its reminders right away. The response lists their next runs in the new
+// time zone.
 func MMReminderTimeZoneSet(
 	app *app.Application,
 	req dtos.MMRequest,
 	tokens []string,
 ) (string, error) {
 	timeZone := tokens[1]
-	if _, err := time.LoadLocation(timeZone); err != nil {
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
 		return "", fmt.Errorf("parse timezone: %w", err)
 	}
 
+	app.ScheduleMu.RLock()
+	defer app.ScheduleMu.RUnlock()
+
 	if err := InsertChannel(
 		app,
 		models.Channel{Name: req.ChannelName, TimeZone: timeZone},
 	); err != nil {
 		return "", fmt.Errorf("insert channel: %w", err)
 	}
-	return timeZone, nil
+	nextRuns := app.RemindManager.RescheduleChannel(req.ChannelName)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Time zone set to %s", timeZone))
+	if len(nextRuns) == 0 {
+		return sb.String(), nil
+	}
+
+	reminders, err := GetRemindersByChannel(app, req.ChannelName)
+	if err != nil {
+		return "", fmt.Errorf("get reminders by channel: %w", err)
+	}
+
+	sb.WriteString("\n\n|Id|Name|Next run|\n|-|-|-|\n")
+	for _, reminder := range reminders {
+		nextRun, ok := nextRuns[reminder.ID]
+		if !ok {
+			continue
+		}
+		sb.WriteString(
+			fmt.Sprintf(
+				"|%d|%s|%s|\n",
+				reminder.ID,
+				rmLineBreaks(reminder.Name),
+				nextRun.In(loc).Format(time.DateTime),
+			),
+		)
+	}
+	return sb.String(), nil
 }
 
 func MMReminderTimeZoneGet(app *app.Application, req dtos.MMRequest) string {
@@ -268,3 +991,178 @@ func MMReminderSetWebhook(
 
 	return "Webhook successfully updated", nil
 }
+
+// MMReminderCalendar manages the calendars of the dates reminders are not
+// sent on: `calendar add NAME DATE... [--global]`, `calendar rm NAME [DATE...]`
+// and `calendar list [NAME]`.
+func MMReminderCalendar(
+	app *app.Application,
+	req dtos.MMRequest,
+	tokens []string,
+) (string, error) {
+	args, opts, err := splitOptions(tokens, "global")
+	if err != nil {
+		return "", err
+	}
+	if err := checkOptions(opts, "global"); err != nil {
+		return "", err
+	}
+	if len(args) < 2 {
+		return "", wrongArgCntErr{}
+	}
+	_, global := opts["global"]
+
+	switch args[1] {
+	case "add":
+		if len(args) < 4 {
+			return "", wrongArgCntErr{}
+		}
+		var dates []models.CalendarDate
+		for _, arg := range args[3:] {
+			days, err := parseDateRange(arg)
+			if err != nil {
+				return "", fmt.Errorf("calendar: %w", err)
+			}
+			for _, day := range days {
+				dates = append(dates, models.CalendarDate{Date: day})
+			}
+		}
+		calendar, err := AddCalendarDates(app, args[2], req.ChannelName, global, dates)
+		if err != nil {
+			return "", fmt.Errorf("calendar: %w", err)
+		}
+		return fmt.Sprintf("%d date(s) added to calendar %s", len(dates), calendarTitle(*calendar)), nil
+
+	case "rm", "remove", "delete", "del":
+		if len(args) < 3 {
+			return "", wrongArgCntErr{}
+		}
+		var dates []time.Time
+		for _, arg := range args[3:] {
+			days, err := parseDateRange(arg)
+			if err != nil {
+				return "", fmt.Errorf("calendar: %w", err)
+			}
+			dates = append(dates, days...)
+		}
+		removed, err := RemoveCalendarDates(app, args[2], req.ChannelName, dates)
+		if err != nil {
+			return "", fmt.Errorf("calendar: %w", err)
+		}
+		if len(dates) == 0 {
+			return fmt.Sprintf("Calendar %s deleted", args[2]), nil
+		}
+		return fmt.Sprintf("%d date(s) removed from calendar %s", removed, args[2]), nil
+
+	case "weekend":
+		if len(args) != 4 {
+			return "", wrongArgCntErr{}
+		}
+		calendar, err := SetCalendarWeekend(app, args[2], req.ChannelName, global, args[3])
+		if err != nil {
+			return "", fmt.Errorf("calendar: %w", err)
+		}
+		return fmt.Sprintf("Weekend of calendar %s set to %s", calendarTitle(*calendar), weekendTitle(*calendar)), nil
+
+	case "list", "ls":
+		if len(args) > 3 {
+			return "", wrongArgCntErr{}
+		}
+		if len(args) == 3 {
+			return mmCalendarDates(app, req, args[2])
+		}
+		calendars, err := GetCalendars(app, req.ChannelName)
+		if err != nil {
+			return "", fmt.Errorf("calendar: %w", err)
+		}
+		if len(calendars) == 0 {
+			return "There are no calendars in this channel yet! Add one using `/reminder calendar add ...`", nil
+		}
+		var sb strings.Builder
+		sb.WriteString("|Id|Name|Channel|Weekend|\n|-|-|-|-|\n")
+		for _, calendar := range calendars {
+			channel := "global"
+			if calendar.Channel.Valid {
+				channel = calendar.Channel.String
+			}
+			sb.WriteString(fmt.Sprintf(
+				"|%d|%s|%s|%s|\n",
+				calendar.ID,
+				calendar.Name,
+				channel,
+				weekendTitle(calendar),
+			))
+		}
+		return sb.String(), nil
+
+	default:
+		return "", fmt.Errorf("calendar: unknown command '%s'", args[1])
+	}
+}
+
+func mmCalendarDates(app *app.Application, req dtos.MMRequest, name string) (string, error) {
+	calendar, err := FindCalendar(app, name, req.ChannelName)
+	if err != nil {
+		return "", fmt.Errorf("calendar: %w", err)
+	}
+	dates, err := GetCalendarDates(app, calendar.ID)
+	if err != nil {
+		return "", fmt.Errorf("calendar: %w", err)
+	}
+	if len(dates) == 0 {
+		return fmt.Sprintf("Calendar %s has no dates", calendarTitle(*calendar)), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Dates of calendar %s:\n", calendarTitle(*calendar)))
+	for _, date := range dates {
+		sb.WriteString("- " + date.Date.Format("Mon 2006-01-02"))
+		if date.Name != "" {
+			sb.WriteString(" " + date.Name)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
+func calendarTitle(calendar models.Calendar) string {
+	if calendar.Channel.Valid {
+		return calendar.Name
+	}
+	return calendar.Name + " (global)"
+}
+
+func weekendTitle(calendar models.Calendar) string {
+	if calendar.Weekend == "" {
+		return "none"
+	}
+	return calendar.Weekend
+}
+
+// maxDateRangeDays bounds the amount of days of a date range.
+const maxDateRangeDays = 366
+
+// parseDateRange reads a date or an inclusive range of dates FROM..TO in the
+// YYYY-MM-DD format.
+func parseDateRange(s string) ([]time.Time, error) {
+	from, to, isRange := strings.Cut(s, "..")
+	start, err := time.Parse(time.DateOnly, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date '%s': expected YYYY-MM-DD", from)
+	}
+	end := start
+	if isRange {
+		if end, err = time.Parse(time.DateOnly, to); err != nil {
+			return nil, fmt.Errorf("invalid date '%s': expected YYYY-MM-DD", to)
+		}
+	}
+	if end.Before(start) || end.Sub(start) >= maxDateRangeDays*24*time.Hour {
+		return nil, fmt.Errorf("invalid date range '%s'", s)
+	}
+
+	var days []time.Time
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days, nil
+}
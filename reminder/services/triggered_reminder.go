@@ -1,7 +1,12 @@
 package services
 
 import (
+	"context"
+	"slices"
+	"time"
+
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/app"
+	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/dtos"
 	"github.com/andrey-dru-me1/mattermost-reminder-bot/reminder/models"
 )
 
@@ -9,6 +14,89 @@ func GetReminds(app *app.Application) []models.Remind {
 	return app.RemindManager.GetReminds()
 }
 
-func CompleteReminds(app *app.Application, ids []int64) {
-	app.RemindManager.CompleteReminds(ids...)
+func ClaimReminds(
+	app *app.Application,
+	claimant string,
+	lease time.Duration,
+) []models.Remind {
+	if lease <= 0 {
+		lease = app.RemindLease
+	}
+	return app.RemindManager.ClaimReminds(claimant, lease)
+}
+
+// WaitReminds claims the reminds ready for delivery like ClaimReminds. If
+// there are none, it waits up to wait for new ones to be triggered.
+func WaitReminds(
+	c context.Context,
+	app *app.Application,
+	claimant string,
+	lease time.Duration,
+	wait time.Duration,
+) []models.Remind {
+	updates, cancel := app.RemindManager.Subscribe()
+	defer cancel()
+
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+
+	for {
+		if reminds := ClaimReminds(app, claimant, lease); len(reminds) > 0 {
+			return reminds
+		}
+
+		select {
+		case <-updates:
+		case <-timeout.C:
+			return nil
+		case <-c.Done():
+			return nil
+		}
+	}
+}
+
+// SubscribeReminds returns a channel signalled whenever new reminds are
+// triggered. The subscription is released by calling cancel.
+func SubscribeReminds(app *app.Application) (<-chan struct{}, func()) {
+	return app.RemindManager.Subscribe()
+}
+
+func CompleteReminds(app *app.Application, occurrenceIDs []string) {
+	app.RemindManager.CompleteReminds(occurrenceIDs...)
+}
+
+// CompleteRemindsOfReminders completes every pending occurrence of the
+// reminders. It serves clients acknowledging reminds by reminder ID.
+func CompleteRemindsOfReminders(app *app.Application, reminderIDs []int64) {
+	var occurrenceIDs []string
+	for _, remind := range app.RemindManager.GetReminds() {
+		if slices.Contains(reminderIDs, remind.ReminderId) {
+			occurrenceIDs = append(occurrenceIDs, remind.OccurrenceID)
+		}
+	}
+	app.RemindManager.CompleteReminds(occurrenceIDs...)
+}
+
+// FailReminds moves the reminds that failed permanently to the dead letters
+// and returns the occurrence IDs that were not pending.
+func FailReminds(app *app.Application, failures []dtos.FailedRemindDTO) []string {
+	var unknown []string
+	for _, failure := range failures {
+		if !app.RemindManager.FailRemind(failure.OccurrenceID, failure.Error) {
+			unknown = append(unknown, failure.OccurrenceID)
+		}
+	}
+	return unknown
+}
+
+func GetDeadReminds(app *app.Application) []models.DeadRemind {
+	return app.RemindManager.GetDeadReminds()
+}
+
+func ReplayReminds(app *app.Application, occurrenceIDs []string) {
+	app.RemindManager.ReplayReminds(occurrenceIDs...)
+}
+
+func DiscardDeadReminds(app *app.Application, occurrenceIDs []string) {
+	app.RemindManager.DiscardDeadReminds(occurrenceIDs...)
 }